@@ -23,6 +23,8 @@ func main() {
 	addr := flag.String("addr", ":9999", "the address the webserver should listen on in dev mode")
 	fake := flag.Bool("fake", false, "whether to generate fake weather and calendar data in dev mode")
 	img := flag.String("img", "screen.png", "the path to save the rendered image")
+	generate := flag.Int("generate", 0, "the number of days to generate the texts of the generated cards for, "+
+		"ahead of the renders, instead of rendering")
 
 	flag.Parse()
 
@@ -37,7 +39,7 @@ func main() {
 		log.Fatal("failed to read config file:", err)
 	}
 
-	if err := internal.Run(cfg, *dev, *fake, *img, *addr); err != nil {
+	if err := internal.Run(cfg, *dev, *fake, *img, *addr, *generate); err != nil {
 		log.Fatal("failed to render:", err)
 	}
 }
@@ -3,80 +3,280 @@ package internal
 import (
 	"encoding/json"
 	"fmt"
+	"html/template"
+	"math"
+	"math/rand"
+	"net/url"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/prongbang/callx"
 )
 
+// AirQualityIndex is the scale the air quality is reported with.
+type AirQualityIndex string
+
+const (
+	AirQualityIndexUS       AirQualityIndex = "us_aqi"
+	AirQualityIndexEuropean AirQualityIndex = "european_aqi"
+	AirQualityIndexAQHI     AirQualityIndex = "aqhi" // The Canadian Air Quality Health Index.
+)
+
 type AirQualityOptions struct {
 	Location      LatLng
+	Timezone      *time.Location // The hours of today are in this timezone.
+	Index         AirQualityIndex
 	RelevantHours HoursOptions
-	Chart         ChartOptions
+	Pollen        PollenOptions
 }
 
-func (c Config) GetAirQualityOptions() AirQualityOptions {
+func (c Config) GetAirQualityOptions() (AirQualityOptions, error) {
+	index := AirQualityIndex(c.Weather.AirQuality.Index)
+	if _, ok := airQualityScales[index]; !ok {
+		return AirQualityOptions{}, fmt.Errorf("unknown air quality index: %s", index)
+	}
+	// A reading in another index would be off the scale of the chart.
+	for _, sensor := range c.Sensors {
+		if sensor.AirQuality != "" && AirQualityIndex(sensor.AirQuality) != index {
+			return AirQualityOptions{}, fmt.Errorf("air quality sensor (%s) uses %s, but the air quality index is %s",
+				sensor.Name, sensor.AirQuality, index)
+		}
+	}
+	pollen, err := c.Weather.Pollen.ToPollenOptions()
+	if err != nil {
+		return AirQualityOptions{}, err
+	}
+	timezone, err := c.GetTimezone()
+	if err != nil {
+		return AirQualityOptions{}, err
+	}
 	return AirQualityOptions{
 		Location:      LatLng(c.Weather.Location),
+		Timezone:      timezone,
+		Index:         index,
 		RelevantHours: c.Weather.AirQuality.Hours.ToHoursOptions(),
-		Chart:         c.Weather.AirQuality.Chart.ToChartOptions(),
+		Pollen:        pollen,
+	}, nil
+}
+
+// airQualityScale holds the official breakpoints of an index, and the chart options derived from them.
+type airQualityScale struct {
+	Categories []airQualityCategory // In increasing order.
+	Chart      ChartOptions
+}
+
+type airQualityCategory struct {
+	Max   int // Inclusive, the last category has no max.
+	Label string
+}
+
+// Category returns the label of the category the value falls in.
+func (s airQualityScale) Category(value int) string {
+	for _, category := range s.Categories[:len(s.Categories)-1] {
+		if value <= category.Max {
+			return category.Label
+		}
 	}
+	return s.Categories[len(s.Categories)-1].Label
+}
+
+// airQualityScales holds the scales of the supported indices. The chart is displayed once the air quality is worse
+// than the first categories, and fully shaded once it's unhealthy.
+var airQualityScales = map[AirQualityIndex]airQualityScale{
+	AirQualityIndexUS: {
+		// See https://www.airnow.gov/aqi/aqi-basics/.
+		Categories: []airQualityCategory{
+			{Max: 50, Label: "Bon"},
+			{Max: 100, Label: "Modéré"},
+			{Max: 150, Label: "Malsain pour les personnes sensibles"},
+			{Max: 200, Label: "Malsain"},
+			{Max: 300, Label: "Très malsain"},
+			{Label: "Dangereux"},
+		},
+		Chart: ChartOptions{Top: 150, Step: 50, Min: 50, High: 150},
+	},
+	AirQualityIndexEuropean: {
+		// See https://airindex.eea.europa.eu.
+		Categories: []airQualityCategory{
+			{Max: 20, Label: "Bon"},
+			{Max: 40, Label: "Acceptable"},
+			{Max: 60, Label: "Modéré"},
+			{Max: 80, Label: "Mauvais"},
+			{Max: 100, Label: "Très mauvais"},
+			{Label: "Extrêmement mauvais"},
+		},
+		Chart: ChartOptions{Top: 80, Step: 20, Min: 40, High: 80},
+	},
+	AirQualityIndexAQHI: {
+		// See https://www.canada.ca/en/environment-climate-change/services/air-quality-health-index.html.
+		Categories: []airQualityCategory{
+			{Max: 3, Label: "Risque faible"},
+			{Max: 6, Label: "Risque modéré"},
+			{Max: 10, Label: "Risque élevé"},
+			{Label: "Risque très élevé"},
+		},
+		Chart: ChartOptions{Top: 10, Step: 1, Min: 3, High: 7},
+	},
+}
+
+// airQualityData holds the hourly air quality for today.
+type airQualityData struct {
+	Times    []time.Time // The start of every hour of today.
+	Index    []int
+	Dominant []string             // The pollutant contributing the most to the index, for every hour.
+	Pollen   map[string][]float64 // The concentrations (grains/m³) of the requested species, for every hour.
 }
 
-// NewAirQualityCard creates a new air quality Card using the given latitude and longitude.
-func NewAirQualityCard(options AirQualityOptions) Card {
+// NewAirQualityCards creates the air quality and pollen Cards using the given latitude and longitude. Both are
+// populated from a single request. A local air quality sensor reading, if any, overrides the current hour.
+func NewAirQualityCards(options AirQualityOptions, sensors SensorsInfo) []Card {
 	var once sync.Once
-	var aqi []int
+	var data airQualityData
 	var err error
 
-	return makeAirQualityCard(options, func() ([]int, error) {
+	return makeAirQualityCards(options, func() (airQualityData, error) {
 		once.Do(func() {
-			aqi, err = fetchAirQualityData(options.Location)
+			data, err = fetchAirQualityData(options, time.Now().In(options.Timezone))
+			if err != nil {
+				return
+			}
+			if err = sensors.Load(); err != nil {
+				return
+			}
+			if reading, ok := sensors.AirQuality(options.Index); ok {
+				now := time.Now()
+				for i, t := range data.Times {
+					if !now.Before(t) && now.Before(t.Add(time.Hour)) {
+						data.Index[i] = int(math.Round(reading))
+					}
+				}
+			}
 		})
-		return aqi, err
+		return data, err
 	})
 }
 
-// NewFakeAirQualityCard creates a new air quality Card with fake data for testing purposes.
-func NewFakeAirQualityCard(options AirQualityOptions) Card {
-	return makeAirQualityCard(options, func() ([]int, error) {
-		return getBiasedSmoothRandomValues(24, 0, 250), nil
+// NewFakeAirQualityCards creates the air quality and pollen Cards with fake data for testing purposes.
+func NewFakeAirQualityCards(options AirQualityOptions) []Card {
+	return makeAirQualityCards(options, func() (airQualityData, error) {
+		scale := airQualityScales[options.Index]
+		times := hoursOfDay(time.Now().In(options.Timezone))
+		data := airQualityData{
+			Times: times,
+			Index: getBiasedSmoothRandomValues(len(times), 0, scale.Chart.High*5/3),
+		}
+		pollutants := []string{"PM2,5", "PM10", "ozone", "NO₂"}
+		for range data.Index {
+			data.Dominant = append(data.Dominant, pollutants[rand.Intn(len(pollutants))])
+		}
+		data.Pollen = map[string][]float64{}
+		for _, species := range options.Pollen.Species {
+			for _, value := range getBiasedSmoothRandomValues(len(times), 0, 300) {
+				data.Pollen[species] = append(data.Pollen[species], float64(value))
+			}
+		}
+		return data, nil
 	})
 }
 
-func makeAirQualityCard(options AirQualityOptions, getAQI func() ([]int, error)) Card {
+func makeAirQualityCards(options AirQualityOptions, getAirQuality func() (airQualityData, error)) []Card {
+	return []Card{
+		makeAirQualityCard(options, getAirQuality),
+		makePollenCard(options.Pollen, getAirQuality),
+	}
+}
+
+func makeAirQualityCard(options AirQualityOptions, getAirQuality func() (airQualityData, error)) Card {
+	scale := airQualityScales[options.Index]
+
 	return Card{
 		Title:    "Qualité de l'air",
 		Type:     CardTypeChart,
 		Priority: 75,
 		loader: func(c *Card) error {
 			c.Chart = Chart{}
-			data, err := getAQI()
+			c.Footer = ""
+			data, err := getAirQuality()
 			if err != nil {
 				return err
 			}
 
 			c.Chart = Chart{
-				Data:    data,
+				Data:    data.Index,
+				Times:   data.Times,
 				Hours:   options.RelevantHours,
-				Options: options.Chart,
+				Options: scale.Chart,
 			}
 
+			// Describe the worst of the relevant hours.
+			peak, ok := relevantMax(data.Index, data.Times, options.RelevantHours)
+			if !ok {
+				return nil
+			}
+			for i, value := range data.Index {
+				hour := hourAt(data.Times, i)
+				if options.RelevantHours.Includes(hour) && value == peak {
+					c.Footer = template.HTML(fmt.Sprintf("%s vers %dh, surtout %s",
+						scale.Category(peak), hour, data.Dominant[i]))
+					break
+				}
+			}
 			return nil
 		},
 	}
 }
 
-func fetchAirQualityData(location LatLng) ([]int, error) {
-	var result []int
+// airQualityPollutants holds the French names of the pollutants, keyed by their Open-Meteo names.
+var airQualityPollutants = map[string]string{
+	"pm2_5":            "PM2,5",
+	"pm10":             "PM10",
+	"ozone":            "ozone",
+	"nitrogen_dioxide": "NO₂",
+	"sulphur_dioxide":  "SO₂",
+	"carbon_monoxide":  "CO",
+}
+
+// airQualityIndexPollutants holds the pollutants of every index. The US and European indices are the highest of the
+// sub-indices of theirs, and the AQHI is computed from the concentrations of its own.
+var airQualityIndexPollutants = map[AirQualityIndex][]string{
+	AirQualityIndexUS:       {"pm2_5", "pm10", "ozone", "nitrogen_dioxide", "sulphur_dioxide", "carbon_monoxide"},
+	AirQualityIndexEuropean: {"pm2_5", "pm10", "ozone", "nitrogen_dioxide", "sulphur_dioxide"},
+	AirQualityIndexAQHI:     {"pm2_5", "ozone", "nitrogen_dioxide"},
+}
+
+// fetchAirQualityData fetches the air quality for the hours of the day of now.
+func fetchAirQualityData(options AirQualityOptions, now time.Time) (airQualityData, error) {
+	result := airQualityData{Pollen: map[string][]float64{}}
+	location, index, species := options.Location, options.Index, options.Pollen.Species
 
 	client := callx.New(callx.Config{
 		BaseURL: "https://air-quality-api.open-meteo.com",
 		Timeout: 10,
 	})
 
+	// The AQHI is computed from the concentrations, the others come with the sub-index of each pollutant.
+	var hourly []string
+	for _, pollutant := range airQualityIndexPollutants[index] {
+		if index == AirQualityIndexAQHI {
+			hourly = append(hourly, pollutant)
+		} else {
+			hourly = append(hourly, fmt.Sprintf("%s_%s", index, pollutant))
+		}
+	}
+	if index != AirQualityIndexAQHI {
+		hourly = append(hourly, string(index))
+	}
+	for _, s := range species {
+		hourly = append(hourly, s+"_pollen")
+	}
+
+	// Yesterday is needed for the 3 hours averages of the AQHI. Tomorrow covers the extra hour of the day DST ends
+	// when the timezone is unknown to Open-Meteo.
 	path := fmt.Sprintf(
-		"/v1/air-quality?latitude=%f&longitude=%f&hourly=us_aqi&forecast_days=1&timezone=auto",
-		location.Lat, location.Lng,
+		"/v1/air-quality?latitude=%f&longitude=%f&hourly=%s&past_days=1&forecast_days=2&timeformat=unixtime"+
+			"&timezone=%s",
+		location.Lat, location.Lng, strings.Join(hourly, ","), url.QueryEscape(openMeteoTimezone(options.Timezone)),
 	)
 
 	resp := client.Get(path)
@@ -85,14 +285,105 @@ func fetchAirQualityData(location LatLng) ([]int, error) {
 	}
 
 	var response struct {
-		Hourly struct {
-			AQI []int `json:"us_aqi"`
-		} `json:"hourly"`
+		Hourly map[string]json.RawMessage `json:"hourly"`
 	}
-
 	if err := json.Unmarshal(resp.Data, &response); err != nil {
-		fmt.Println(string(resp.Data))
 		return result, err
 	}
-	return response.Hourly.AQI, nil
+	var times []int64
+	if err := json.Unmarshal(response.Hourly["time"], &times); err != nil {
+		return result, fmt.Errorf("failed to parse air quality times: %w", err)
+	}
+
+	// Missing values are null, and left at zero.
+	series := map[string][]float64{}
+	for _, name := range hourly {
+		var values []*float64
+		if err := json.Unmarshal(response.Hourly[name], &values); err != nil {
+			return result, fmt.Errorf("failed to parse air quality data (%s): %w", name, err)
+		}
+		if len(values) != len(times) {
+			return result, fmt.Errorf("unexpected air quality data length")
+		}
+		for _, value := range values {
+			if value == nil {
+				series[name] = append(series[name], 0)
+			} else {
+				series[name] = append(series[name], *value)
+			}
+		}
+	}
+
+	// Keep the hours of today, after the ones of yesterday needed by the AQHI.
+	today := midnight(now)
+	tomorrow := today.AddDate(0, 0, 1)
+	var hours []int
+	for i, unix := range times {
+		t := time.Unix(unix, 0).In(now.Location())
+		if i >= 2 && !t.Before(today) && t.Before(tomorrow) {
+			result.Times = append(result.Times, t)
+			hours = append(hours, i)
+		}
+	}
+	if len(hours) == 0 {
+		return result, fmt.Errorf("no air quality data for today")
+	}
+
+	for _, hour := range hours {
+		var value float64
+		var dominant string
+		if index == AirQualityIndexAQHI {
+			value, dominant = aqhi(series, hour)
+		} else {
+			value = series[string(index)][hour]
+			dominant = dominantPollutant(series, index, hour)
+		}
+		result.Index = append(result.Index, int(math.Round(value)))
+		result.Dominant = append(result.Dominant, dominant)
+	}
+	for _, s := range species {
+		for _, hour := range hours {
+			result.Pollen[s] = append(result.Pollen[s], series[s+"_pollen"][hour])
+		}
+	}
+	return result, nil
+}
+
+// dominantPollutant returns the name of the pollutant with the highest sub-index of the US or European index at the
+// given hour.
+func dominantPollutant(series map[string][]float64, index AirQualityIndex, hour int) string {
+	highest := -1.0
+	var dominant string
+	for _, pollutant := range airQualityIndexPollutants[index] {
+		if sub := series[fmt.Sprintf("%s_%s", index, pollutant)][hour]; sub > highest {
+			highest, dominant = sub, airQualityPollutants[pollutant]
+		}
+	}
+	return dominant
+}
+
+// aqhi returns the AQHI at the given hour from the 3 hours averages of the concentrations (µg/m³), along with the
+// pollutant contributing the most to it.
+func aqhi(series map[string][]float64, hour int) (float64, string) {
+	average := func(pollutant string) float64 {
+		values := series[pollutant][hour-2 : hour+1]
+		return (values[0] + values[1] + values[2]) / 3
+	}
+
+	// The formula uses ppb for the gases.
+	terms := map[string]float64{
+		"ozone":            math.Exp(0.000537*average("ozone")/1.96) - 1,
+		"nitrogen_dioxide": math.Exp(0.000871*average("nitrogen_dioxide")/1.88) - 1,
+		"pm2_5":            math.Exp(0.000487*average("pm2_5")) - 1,
+	}
+
+	sum, highest := 0.0, -1.0
+	var dominant string
+	for pollutant, term := range terms {
+		sum += term
+		if term > highest {
+			highest, dominant = term, airQualityPollutants[pollutant]
+		}
+	}
+	return max(1, 1000/10.4*sum), dominant
 }
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAQHI(t *testing.T) {
+	// The concentrations of the 3 hours up to the last, with the gases in µg/m³: 20 ppb of NO₂ and 40 ppb of ozone on
+	// average. The published formula gives 100/10.4 × ((e^(0.000871×20)-1) + (e^(0.000537×40)-1) +
+	// (e^(0.000487×15)-1)) = 4.48.
+	series := map[string][]float64{
+		"nitrogen_dioxide": {0, 30 * 1.88, 20 * 1.88, 10 * 1.88},
+		"ozone":            {0, 40 * 1.96, 40 * 1.96, 40 * 1.96},
+		"pm2_5":            {0, 5, 15, 25},
+	}
+	value, dominant := aqhi(series, 3)
+	if math.Abs(value-4.482) > 0.001 {
+		t.Errorf("got an AQHI of %v, want 4.482", value)
+	}
+	if dominant != "ozone" {
+		t.Errorf("got %s as the dominant pollutant, want ozone", dominant)
+	}
+
+	// Clean air is still at the bottom of the scale.
+	clean := map[string][]float64{
+		"nitrogen_dioxide": {2 * 1.88, 2 * 1.88, 2 * 1.88},
+		"ozone":            {5 * 1.96, 5 * 1.96, 5 * 1.96},
+		"pm2_5":            {1, 1, 1},
+	}
+	if value, _ := aqhi(clean, 2); value != 1 {
+		t.Errorf("got an AQHI of %v for clean air, want 1", value)
+	}
+}
+
+func TestDominantPollutant(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		index  AirQualityIndex
+		series map[string][]float64
+		want   string
+	}{
+		{
+			name:  "smog",
+			index: AirQualityIndexUS,
+			series: map[string][]float64{
+				"us_aqi_pm2_5": {40}, "us_aqi_pm10": {20}, "us_aqi_ozone": {120}, "us_aqi_nitrogen_dioxide": {30},
+				"us_aqi_sulphur_dioxide": {5}, "us_aqi_carbon_monoxide": {3},
+			},
+			want: "ozone",
+		},
+		{
+			name:  "dust",
+			index: AirQualityIndexUS,
+			series: map[string][]float64{
+				"us_aqi_pm2_5": {60}, "us_aqi_pm10": {160}, "us_aqi_ozone": {40}, "us_aqi_nitrogen_dioxide": {30},
+				"us_aqi_sulphur_dioxide": {5}, "us_aqi_carbon_monoxide": {3},
+			},
+			want: "PM10",
+		},
+		{
+			name:  "smelter",
+			index: AirQualityIndexUS,
+			series: map[string][]float64{
+				"us_aqi_pm2_5": {60}, "us_aqi_pm10": {40}, "us_aqi_ozone": {20}, "us_aqi_nitrogen_dioxide": {30},
+				"us_aqi_sulphur_dioxide": {110}, "us_aqi_carbon_monoxide": {3},
+			},
+			want: "SO₂",
+		},
+		{
+			name:  "smoke",
+			index: AirQualityIndexEuropean,
+			series: map[string][]float64{
+				"european_aqi_pm2_5": {70}, "european_aqi_pm10": {90}, "european_aqi_ozone": {30},
+				"european_aqi_nitrogen_dioxide": {10}, "european_aqi_sulphur_dioxide": {2},
+			},
+			want: "PM10",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := dominantPollutant(test.series, test.index, 0); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAirQualityScaleCategory(t *testing.T) {
+	for _, test := range []struct {
+		index AirQualityIndex
+		value int
+		want  string
+	}{
+		{AirQualityIndexUS, 0, "Bon"},
+		{AirQualityIndexUS, 50, "Bon"},
+		{AirQualityIndexUS, 51, "Modéré"},
+		{AirQualityIndexUS, 150, "Malsain pour les personnes sensibles"},
+		{AirQualityIndexUS, 151, "Malsain"},
+		{AirQualityIndexUS, 300, "Très malsain"},
+		{AirQualityIndexUS, 301, "Dangereux"},
+		{AirQualityIndexUS, 500, "Dangereux"},
+		{AirQualityIndexEuropean, 20, "Bon"},
+		{AirQualityIndexEuropean, 21, "Acceptable"},
+		{AirQualityIndexEuropean, 100, "Très mauvais"},
+		{AirQualityIndexEuropean, 101, "Extrêmement mauvais"},
+		{AirQualityIndexAQHI, 1, "Risque faible"},
+		{AirQualityIndexAQHI, 3, "Risque faible"},
+		{AirQualityIndexAQHI, 4, "Risque modéré"},
+		{AirQualityIndexAQHI, 7, "Risque élevé"},
+		{AirQualityIndexAQHI, 10, "Risque élevé"},
+		{AirQualityIndexAQHI, 11, "Risque très élevé"},
+	} {
+		if got := airQualityScales[test.index].Category(test.value); got != test.want {
+			t.Errorf("got %s for %d on the %s scale, want %s", got, test.value, test.index, test.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AlertsOptions holds options for creating the severe weather alerts Card.
+type AlertsOptions struct {
+	FeedURL   string // An Atom feed of CAP alerts for the location.
+	UserAgent string
+	Timezone  *time.Location // The times of the alerts are given in this timezone.
+}
+
+func (c Config) GetAlertsOptions() (AlertsOptions, error) {
+	timezone, err := c.GetTimezone()
+	if err != nil {
+		return AlertsOptions{}, err
+	}
+	return AlertsOptions{
+		FeedURL:   c.Weather.Alerts.FeedURL,
+		UserAgent: c.Weather.Alerts.UserAgent,
+		Timezone:  timezone,
+	}, nil
+}
+
+// NewAlertsCard creates a new Card with the active severe weather alerts from the configured feed.
+func NewAlertsCard(options AlertsOptions) Card {
+	var once sync.Once
+	var alerts []alert
+	var err error
+
+	if options.FeedURL == "" {
+		return Card{}
+	}
+
+	return makeAlertsCard(func() ([]alert, error) {
+		once.Do(func() {
+			alerts, err = fetchAlerts(options)
+		})
+		return alerts, err
+	})
+}
+
+// NewFakeAlertsCard creates a new alerts Card with fake data for testing purposes.
+func NewFakeAlertsCard() Card {
+	return makeAlertsCard(func() ([]alert, error) {
+		now := time.Now()
+		return []alert{
+			{
+				Headline: "Avertissement de pluie verglaçante",
+				Onset:    now.Add(3 * time.Hour),
+				Ends:     now.Add(27 * time.Hour),
+			},
+		}, nil
+	})
+}
+
+type alert struct {
+	Headline string
+	Onset    time.Time // Zero when unknown.
+	Ends     time.Time // Zero when unknown, or the expiry of the message when the end of the event is.
+}
+
+func makeAlertsCard(getAlerts func() ([]alert, error)) Card {
+	return Card{
+		Title:    "Alertes météo",
+		Type:     CardTypeList,
+		Priority: 1000, // Above everything else.
+		loader: func(c *Card) error {
+			c.Items = []string{}
+			alerts, err := getAlerts()
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			for _, a := range alerts {
+				item := a.Headline
+				switch {
+				case a.Onset.After(now) && !a.Ends.IsZero():
+					item += fmt.Sprintf(", de %s à %s", formatAlertTime(a.Onset), formatAlertTime(a.Ends))
+				case a.Onset.After(now):
+					item += fmt.Sprintf(", à partir de %s", formatAlertTime(a.Onset))
+				case !a.Ends.IsZero():
+					item += fmt.Sprintf(", jusqu'à %s", formatAlertTime(a.Ends))
+				}
+				// The same alert is often issued for many zones.
+				if !slices.Contains(c.Items, item) {
+					c.Items = append(c.Items, item)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// formatAlertTime formats a time with the day of the week, e.g. mardi 18h00.
+func formatAlertTime(t time.Time) string {
+	return strings.ToLower(replacer.Replace(t.Format("Monday 15h04")))
+}
+
+func fetchAlerts(options AlertsOptions) ([]alert, error) {
+	req, err := http.NewRequest(http.MethodGet, options.FeedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The NWS requires an identifying user agent.
+	req.Header.Set("User-Agent", options.UserAgent)
+	req.Header.Set("Accept", "application/atom+xml")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get weather alerts: %s", string(body))
+	}
+	return parseAlerts(body, time.Now().In(options.Timezone))
+}
+
+// parseAlerts parses an Atom feed of alerts, keeping only those in effect at now or later, with their times in the
+// timezone of now. The CAP fields of the NWS feeds are used when present, otherwise the entries of feeds like
+// Environment Canada's are taken as is, except for the warnings that ended.
+func parseAlerts(body []byte, now time.Time) ([]alert, error) {
+	var feed struct {
+		Entries []struct {
+			Title   string `xml:"http://www.w3.org/2005/Atom title"`
+			Event   string `xml:"urn:oasis:names:tc:emergency:cap:1.2 event"`
+			Status  string `xml:"urn:oasis:names:tc:emergency:cap:1.2 status"`
+			MsgType string `xml:"urn:oasis:names:tc:emergency:cap:1.2 msgType"`
+			Onset   string `xml:"urn:oasis:names:tc:emergency:cap:1.2 onset"`
+			Expires string `xml:"urn:oasis:names:tc:emergency:cap:1.2 expires"` // Of the message.
+			Ends    string `xml:"urn:oasis:names:tc:emergency:cap:1.2 ends"`    // Of the event.
+		} `xml:"http://www.w3.org/2005/Atom entry"`
+	}
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("failed to parse weather alerts: %w", err)
+	}
+
+	var alerts []alert
+	for _, entry := range feed.Entries {
+		// Skip the exercises, tests, and cancellations.
+		if entry.Status != "" && entry.Status != "Actual" {
+			continue
+		}
+		if entry.MsgType == "Cancel" {
+			continue
+		}
+		// Feeds without any alert have a single entry saying so.
+		title := strings.TrimSpace(entry.Title)
+		if strings.HasPrefix(title, "No watches or warnings") || strings.HasPrefix(title, "Aucune veille") {
+			continue
+		}
+		if alertEnded(title) {
+			continue
+		}
+
+		a := alert{Headline: strings.TrimSpace(entry.Event)}
+		if a.Headline == "" {
+			a.Headline = title
+		}
+		if a.Headline == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, entry.Onset); err == nil {
+			a.Onset = t.In(now.Location())
+		}
+		expires, err := time.Parse(time.RFC3339, entry.Expires)
+		if err == nil {
+			if expires.Before(now) {
+				continue
+			}
+			a.Ends = expires.In(now.Location())
+		}
+		if t, err := time.Parse(time.RFC3339, entry.Ends); err == nil {
+			a.Ends = t.In(now.Location())
+		}
+		if !a.Ends.IsZero() && a.Ends.Before(now) {
+			continue
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, nil
+}
+
+// alertEnded returns whether the title announces the end of a warning, e.g. AVERTISSEMENT DE VENT TERMINÉ, Montréal.
+// Environment Canada keeps them in its feeds for a while, without any time.
+func alertEnded(title string) bool {
+	headline, _, _ := strings.Cut(strings.ToUpper(title), ",")
+	headline = strings.TrimSpace(headline)
+	for _, suffix := range []string{" TERMINÉ", " TERMINÉE", " ENDED"} {
+		if strings.HasSuffix(headline, suffix) {
+			return true
+		}
+	}
+	return false
+}
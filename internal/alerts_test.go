@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAlerts(t *testing.T) {
+	seattle := loadTestLocation(t, "America/Los_Angeles")
+	montreal := loadTestLocation(t, "America/Montreal")
+	// The storm warning ends after its message expires, the frost advisory only has the expiry.
+	storm := alert{
+		Headline: "Winter Storm Warning",
+		Onset:    time.Date(2025, time.December, 11, 4, 0, 0, 0, seattle),
+		Ends:     time.Date(2025, time.December, 12, 4, 0, 0, 0, seattle),
+	}
+	frost := alert{
+		Headline: "Frost Advisory",
+		Onset:    time.Date(2025, time.December, 10, 20, 0, 0, 0, seattle),
+		Ends:     time.Date(2025, time.December, 11, 9, 0, 0, 0, seattle),
+	}
+	statement := alert{Headline: "Special Weather Statement"}
+
+	for _, test := range []struct {
+		name    string
+		fixture string
+		now     time.Time
+		want    []alert
+	}{
+		{
+			// The expired wind advisory, the cancelled flood watch, the test, and the exercise are skipped.
+			name:    "NWS",
+			fixture: "alerts_nws.atom",
+			now:     time.Date(2025, time.December, 10, 14, 10, 0, 0, seattle),
+			want:    []alert{storm, frost, statement},
+		},
+		{
+			name:    "NWS once the frost advisory expired",
+			fixture: "alerts_nws.atom",
+			now:     time.Date(2025, time.December, 11, 10, 0, 0, 0, seattle),
+			want:    []alert{storm, statement},
+		},
+		{
+			name:    "NWS once the storm is over",
+			fixture: "alerts_nws.atom",
+			now:     time.Date(2025, time.December, 12, 5, 0, 0, 0, seattle),
+			want:    []alert{statement},
+		},
+		{
+			// The ended warning and watch are skipped.
+			name:    "Environment Canada",
+			fixture: "alerts_eccc.atom",
+			now:     time.Date(2025, time.December, 10, 14, 10, 0, 0, montreal),
+			want: []alert{
+				{Headline: "AVERTISSEMENT DE PLUIE VERGLAÇANTE EN VIGUEUR, Montréal"},
+				{Headline: "BULLETIN MÉTÉOROLOGIQUE SPÉCIAL EN VIGUEUR, Montréal"},
+			},
+		},
+		{
+			name:    "Environment Canada in English",
+			fixture: "alerts_eccc_en.atom",
+			now:     time.Date(2025, time.December, 10, 14, 10, 0, 0, montreal),
+			want:    []alert{{Headline: "SPECIAL WEATHER STATEMENT IN EFFECT, Montréal"}},
+		},
+		{
+			name:    "Environment Canada without alerts",
+			fixture: "alerts_eccc_none.atom",
+			now:     time.Date(2025, time.December, 10, 14, 10, 0, 0, montreal),
+		},
+		{
+			name:    "Environment Canada without alerts in English",
+			fixture: "alerts_eccc_none_en.atom",
+			now:     time.Date(2025, time.December, 10, 14, 10, 0, 0, montreal),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			alerts, err := parseAlerts(readTestData(t, test.fixture), test.now)
+			if err != nil {
+				t.Fatalf("parseAlerts() failed: %v", err)
+			}
+			if len(alerts) != len(test.want) {
+				t.Fatalf("got %d alerts %+v, want %d %+v", len(alerts), alerts, len(test.want), test.want)
+			}
+			for i, want := range test.want {
+				got := alerts[i]
+				if got.Headline != want.Headline || !got.Onset.Equal(want.Onset) || !got.Ends.Equal(want.Ends) {
+					t.Errorf("got alert %+v, want %+v", got, want)
+				}
+				if !got.Onset.IsZero() && got.Onset.Location() != test.now.Location() {
+					t.Errorf("got the onset in %v, want it in %v", got.Onset.Location(), test.now.Location())
+				}
+			}
+		})
+	}
+}
+
+func TestParseAlertsInvalid(t *testing.T) {
+	if _, err := parseAlerts([]byte("<feed><entry>"), time.Now()); err == nil {
+		t.Errorf("parseAlerts() succeeded with an invalid feed")
+	}
+}
+
+func TestFormatAlertTime(t *testing.T) {
+	seattle := loadTestLocation(t, "America/Los_Angeles")
+	onset := time.Date(2025, time.December, 11, 12, 0, 0, 0, time.UTC).In(seattle)
+	if got := formatAlertTime(onset); got != "jeudi 04h00" {
+		t.Errorf("got %s, want jeudi 04h00", got)
+	}
+}
@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BirthdaysOptions holds options for creating the birthdays Card.
+type BirthdaysOptions struct {
+	File       string // A vCard (.vcf) or CSV (.csv) contacts export.
+	NameColumn string // The CSV column holding the name.
+	DateColumn string // The CSV column holding the birthday.
+	Priority   int
+	Timezone   *time.Location // Today is the current day in this timezone.
+}
+
+func (c Config) GetBirthdaysOptions() (BirthdaysOptions, error) {
+	timezone, err := c.GetTimezone()
+	if err != nil {
+		return BirthdaysOptions{}, err
+	}
+	return BirthdaysOptions{
+		File:       c.Birthdays.File,
+		NameColumn: c.Birthdays.NameColumn,
+		DateColumn: c.Birthdays.DateColumn,
+		Priority:   c.Birthdays.Priority,
+		Timezone:   timezone,
+	}, nil
+}
+
+// GeneratedContext returns extra context to give to the LLM when generating the cards of the given day.
+type GeneratedContext func(day time.Time) (string, error)
+
+// NewBirthdaysCardAndContext creates a new birthdays Card using the given options, along with a GeneratedContext
+// naming the people celebrating their birthday on the day of the cards.
+func NewBirthdaysCardAndContext(options BirthdaysOptions) (Card, GeneratedContext) {
+	var once sync.Once
+	var birthdays []birthday
+	var err error
+
+	if options.File == "" {
+		return Card{}, nil
+	}
+
+	return makeBirthdaysCardAndContext(options, func() ([]birthday, error) {
+		once.Do(func() {
+			birthdays, err = readBirthdays(options)
+		})
+		return birthdays, err
+	})
+}
+
+// NewFakeBirthdaysCardAndContext creates a new birthdays Card and GeneratedContext with fake data for testing purposes.
+func NewFakeBirthdaysCardAndContext(options BirthdaysOptions) (Card, GeneratedContext) {
+	return makeBirthdaysCardAndContext(options, func() ([]birthday, error) {
+		now := time.Now().In(options.Timezone)
+		later := now.AddDate(0, 0, 4)
+		return []birthday{
+			{Name: "Julie", Month: now.Month(), Day: now.Day(), Year: now.Year() - 8},
+			{Name: "Papi", Month: later.Month(), Day: later.Day(), Year: now.Year() - 70},
+			{Name: "Tante Sophie", Month: later.Month(), Day: later.Day()},
+		}, nil
+	})
+}
+
+type birthday struct {
+	Name  string
+	Month time.Month
+	Day   int
+	Year  int // Zero when the year of birth is unknown.
+}
+
+// Next returns the next occurrence of the birthday, on or after the given day.
+func (b birthday) Next(today time.Time) time.Time {
+	return DateOptions{Month: b.Month, Day: b.Day}.Next(today)
+}
+
+// Age returns the age reached on the given date, or zero when the year of birth is unknown.
+func (b birthday) Age(on time.Time) int {
+	if b.Year == 0 {
+		return 0
+	}
+	return on.Year() - b.Year
+}
+
+func makeBirthdaysCardAndContext(options BirthdaysOptions, getBirthdays func() ([]birthday, error)) (Card, GeneratedContext) {
+	// Returns the birthdays in the coming week, sorted by date.
+	upcoming := func() ([]birthday, error) {
+		birthdays, err := getBirthdays()
+		if err != nil {
+			return nil, err
+		}
+
+		today := midnight(time.Now().In(options.Timezone))
+		week := today.AddDate(0, 0, 7)
+		upcoming := slices.DeleteFunc(slices.Clone(birthdays), func(b birthday) bool {
+			return !b.Next(today).Before(week)
+		})
+		slices.SortStableFunc(upcoming, func(a, b birthday) int {
+			return cmp.Compare(a.Next(today).Unix(), b.Next(today).Unix())
+		})
+		return upcoming, nil
+	}
+
+	return Card{
+			Title:    "Anniversaires",
+			Type:     CardTypeList,
+			Priority: options.Priority,
+			loader: func(c *Card) error {
+				c.Items = []string{}
+				birthdays, err := upcoming()
+				if err != nil {
+					return err
+				}
+
+				today := midnight(time.Now().In(options.Timezone))
+				for _, b := range birthdays {
+					next := b.Next(today)
+					age := b.Age(next)
+
+					var item string
+					switch {
+					case next.Equal(today) && age > 0:
+						item = fmt.Sprintf("%s a %d ans aujourd'hui!", b.Name, age)
+					case next.Equal(today):
+						item = fmt.Sprintf("Fête de %s aujourd'hui!", b.Name)
+					case age > 0:
+						item = fmt.Sprintf("%s: %s (%d ans)", replacer.Replace(next.Format("Monday")), b.Name, age)
+					default:
+						item = fmt.Sprintf("%s: %s", replacer.Replace(next.Format("Monday")), b.Name)
+					}
+					c.Items = append(c.Items, item)
+				}
+				return nil
+			},
+		},
+		func(day time.Time) (string, error) {
+			birthdays, err := getBirthdays()
+			if err != nil {
+				return "", err
+			}
+
+			day = midnight(day)
+			var names []string
+			for _, b := range birthdays {
+				if !b.Next(day).Equal(day) {
+					continue
+				}
+				if age := b.Age(day); age > 0 {
+					names = append(names, fmt.Sprintf("%s, who turns %d", b.Name, age))
+				} else {
+					names = append(names, b.Name)
+				}
+			}
+			if len(names) == 0 {
+				return "", nil
+			}
+			return fmt.Sprintf("Today is the birthday of %s.", strings.Join(names, "; ")), nil
+		}
+}
+
+func readBirthdays(options BirthdaysOptions) ([]birthday, error) {
+	file, err := os.Open(options.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open birthdays file: %w", err)
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(options.File)) {
+	case ".vcf", ".vcard":
+		return readVCardBirthdays(file)
+	case ".csv":
+		return readCSVBirthdays(file, options.NameColumn, options.DateColumn)
+	}
+	return nil, fmt.Errorf("unsupported birthdays file: %s", options.File)
+}
+
+// readVCardBirthdays reads the FN and BDAY properties of every contact in a vCard file. Contacts with a birthday that
+// can't be parsed are skipped, so one odd date doesn't hide all the others.
+func readVCardBirthdays(r io.Reader) ([]birthday, error) {
+	var birthdays []birthday
+
+	// Unfold the continuation lines first, they start with a space or a tab.
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vCard: %w", err)
+	}
+
+	var name, date string
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		// Ignore the property parameters, e.g. BDAY;VALUE=date.
+		key, _, _ = strings.Cut(strings.ToUpper(key), ";")
+
+		switch key {
+		case "BEGIN":
+			name, date = "", ""
+		case "FN":
+			name = strings.ReplaceAll(value, `\,`, ",")
+		case "BDAY":
+			date = value
+		case "END":
+			if name == "" || date == "" {
+				continue
+			}
+			b, err := parseBirthday(name, date)
+			if err != nil {
+				log.Printf("skipping birthday: %v", err)
+				continue
+			}
+			birthdays = append(birthdays, b)
+		}
+	}
+	return birthdays, nil
+}
+
+// readCSVBirthdays reads the name and birthday columns of every row in a CSV file with a header. Like with vCards, rows
+// with a birthday that can't be parsed are skipped.
+func readCSVBirthdays(r io.Reader, nameColumn, dateColumn string) ([]birthday, error) {
+	var birthdays []birthday
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	nameIndex := slices.Index(header, nameColumn)
+	dateIndex := slices.Index(header, dateColumn)
+	if nameIndex < 0 || dateIndex < 0 {
+		return nil, fmt.Errorf("missing %q or %q column in CSV", nameColumn, dateColumn)
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return birthdays, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if max(nameIndex, dateIndex) >= len(record) {
+			continue
+		}
+		name, date := strings.TrimSpace(record[nameIndex]), strings.TrimSpace(record[dateIndex])
+		if name == "" || date == "" {
+			continue
+		}
+		b, err := parseBirthday(name, date)
+		if err != nil {
+			log.Printf("skipping birthday: %v", err)
+			continue
+		}
+		birthdays = append(birthdays, b)
+	}
+	return birthdays, nil
+}
+
+// parseBirthday parses the birthday formats found in contacts exports, with or without a year.
+func parseBirthday(name, date string) (birthday, error) {
+	// Drop any time component, e.g. 1980-01-15T00:00:00Z.
+	date, _, _ = strings.Cut(strings.TrimSpace(date), "T")
+
+	for _, layout := range []string{"2006-01-02", "20060102"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return birthday{Name: name, Month: t.Month(), Day: t.Day(), Year: t.Year()}, nil
+		}
+	}
+	for _, layout := range []string{"--01-02", "--0102"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return birthday{Name: name, Month: t.Month(), Day: t.Day()}, nil
+		}
+	}
+	return birthday{}, fmt.Errorf("failed to parse birthday of %s: %q", name, date)
+}
@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBirthday(t *testing.T) {
+	for _, test := range []struct {
+		date    string
+		want    birthday
+		wantErr bool
+	}{
+		{date: "1980-01-15", want: birthday{Name: "Papi", Month: time.January, Day: 15, Year: 1980}},
+		{date: "19800115", want: birthday{Name: "Papi", Month: time.January, Day: 15, Year: 1980}},
+		{date: "1980-01-15T00:00:00Z", want: birthday{Name: "Papi", Month: time.January, Day: 15, Year: 1980}},
+		{date: " --0315 ", want: birthday{Name: "Papi", Month: time.March, Day: 15}},
+		{date: "--03-15", want: birthday{Name: "Papi", Month: time.March, Day: 15}},
+		{date: "2000-02-29", want: birthday{Name: "Papi", Month: time.February, Day: 29, Year: 2000}},
+		{date: "--0229", want: birthday{Name: "Papi", Month: time.February, Day: 29}},
+		{date: "2023-02-29", wantErr: true},
+		{date: "--0230", wantErr: true},
+		{date: "15/01/1980", wantErr: true},
+	} {
+		t.Run(test.date, func(t *testing.T) {
+			got, err := parseBirthday("Papi", test.date)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBirthday() failed: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBirthdayNextLeapDay(t *testing.T) {
+	b := birthday{Name: "Julie", Month: time.February, Day: 29, Year: 2016}
+	for _, test := range []struct {
+		today time.Time
+		want  time.Time
+	}{
+		// Celebrated on March 1st when there's no February 29th.
+		{time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC), time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, time.March, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2028, time.February, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	} {
+		if got := b.Next(test.today); !got.Equal(test.want) {
+			t.Errorf("got %v after %v, want %v", got, test.today, test.want)
+		}
+	}
+}
+
+func TestReadVCardBirthdays(t *testing.T) {
+	vcard := strings.Join([]string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"FN:Marie-Ève Tremblay-Gagnon\\, fille de Jean-Pierre et de Marie-Claude de la rue des Érables",
+		"  Lajoie",
+		"BDAY;VALUE=date:1980-",
+		"\t01-15",
+		"END:VCARD",
+		"BEGIN:VCARD",
+		"FN:Oncle Bob",
+		"BDAY:le 3 mars", // Skipped.
+		"END:VCARD",
+		"BEGIN:VCARD",
+		"FN:Tante Sophie",
+		"BDAY:--0315",
+		"END:VCARD",
+		"BEGIN:VCARD",
+		"FN:Sans date",
+		"END:VCARD",
+	}, "\r\n")
+
+	birthdays, err := readVCardBirthdays(strings.NewReader(vcard))
+	if err != nil {
+		t.Fatalf("readVCardBirthdays() failed: %v", err)
+	}
+	want := []birthday{
+		{
+			Name:  "Marie-Ève Tremblay-Gagnon, fille de Jean-Pierre et de Marie-Claude de la rue des Érables Lajoie",
+			Month: time.January, Day: 15, Year: 1980,
+		},
+		{Name: "Tante Sophie", Month: time.March, Day: 15},
+	}
+	if len(birthdays) != len(want) {
+		t.Fatalf("got %+v, want %+v", birthdays, want)
+	}
+	for i := range want {
+		if birthdays[i] != want[i] {
+			t.Errorf("got %+v, want %+v", birthdays[i], want[i])
+		}
+	}
+}
+
+func TestReadCSVBirthdays(t *testing.T) {
+	csv := "Name,Birthday\nPapi,1955-06-01\nOncle Bob,le 3 mars\nTante Sophie,--0315\nSans date,\n"
+
+	birthdays, err := readCSVBirthdays(strings.NewReader(csv), "Name", "Birthday")
+	if err != nil {
+		t.Fatalf("readCSVBirthdays() failed: %v", err)
+	}
+	want := []birthday{
+		{Name: "Papi", Month: time.June, Day: 1, Year: 1955},
+		{Name: "Tante Sophie", Month: time.March, Day: 15},
+	}
+	if len(birthdays) != len(want) {
+		t.Fatalf("got %+v, want %+v", birthdays, want)
+	}
+	for i := range want {
+		if birthdays[i] != want[i] {
+			t.Errorf("got %+v, want %+v", birthdays[i], want[i])
+		}
+	}
+}
@@ -18,10 +18,15 @@ import (
 type CalendarOptions struct {
 	URL       string
 	Attendees *regexp.Regexp
+	Timezone  *time.Location // The days and times of the events are in this timezone.
 }
 
 func (c Config) GetCalendarOptions() ([]CalendarOptions, error) {
 	var calendars []CalendarOptions
+	timezone, err := c.GetTimezone()
+	if err != nil {
+		return calendars, err
+	}
 	for _, cal := range c.Calendars {
 		var filter *regexp.Regexp
 		if len(cal.AttendeesRegExp) != 0 {
@@ -32,7 +37,7 @@ func (c Config) GetCalendarOptions() ([]CalendarOptions, error) {
 			}
 
 		}
-		calendars = append(calendars, CalendarOptions{URL: cal.URL, Attendees: filter})
+		calendars = append(calendars, CalendarOptions{URL: cal.URL, Attendees: filter, Timezone: timezone})
 	}
 	return calendars, nil
 }
@@ -51,13 +56,30 @@ func (c CalendarOptions) MatchesFilter(event gocal.Event) bool {
 	return false
 }
 
-// NewCalendarCards creates new calendar Cards using the given options.
-func NewCalendarCards(options []CalendarOptions) []Card {
+// CalendarInfo holds the events for today and tomorrow.
+// The loader func should be used to populate the data.
+type CalendarInfo struct {
+	Today    []string
+	Tomorrow []string
+
+	loader func(*CalendarInfo) error
+}
+
+// Load populates the CalendarInfo by calling its loader function.
+func (c *CalendarInfo) Load() error {
+	if c.loader != nil {
+		return c.loader(c)
+	}
+	return nil
+}
+
+// NewCalendarCardsAndInfo creates new calendar Cards and CalendarInfo using the given options.
+func NewCalendarCardsAndInfo(options []CalendarOptions) ([]Card, CalendarInfo) {
 	var once sync.Once
 	var cal calendar
 	var err error
 
-	return makeCalendarCards(func() (calendar, error) {
+	return makeCalendarCardsAndInfo(func() (calendar, error) {
 		once.Do(func() {
 			cal, err = fetchCalendars(options)
 		})
@@ -65,14 +87,14 @@ func NewCalendarCards(options []CalendarOptions) []Card {
 	})
 }
 
-// NewFakeCalendarCards creates new calendar Cards with fake data for testing purposes.
-func NewFakeCalendarCards() []Card {
+// NewFakeCalendarCardsAndInfo creates new calendar Cards and CalendarInfo with fake data for testing purposes.
+func NewFakeCalendarCardsAndInfo() ([]Card, CalendarInfo) {
 	var (
 		mu     sync.Mutex
 		cached calendar
 		last   time.Time
 	)
-	return makeCalendarCards(func() (calendar, error) {
+	return makeCalendarCardsAndInfo(func() (calendar, error) {
 		mu.Lock()
 		defer mu.Unlock()
 
@@ -126,43 +148,16 @@ func fetchCalendars(options []CalendarOptions) (calendar, error) {
 	var calendar calendar
 
 	for _, c := range options {
-		resp, err := http.Get(c.URL)
-		if err != nil {
-			return calendar, err
-		}
-		defer resp.Body.Close()
-
-		now := time.Now()
-
 		// We only care about events today and tomorrow.
-		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		tomorrow := today.Add(24 * time.Hour)
-
-		// To catch all-day events, let them start one second before today.
-		start := today.Add(-1 * time.Second)
-		end := tomorrow.Add(24 * time.Hour)
-
-		// Parse events for today and tomorrow.
-		cal := gocal.NewParser(resp.Body)
-		cal.AllDayEventsTZ = now.Location()
-		cal.Start, cal.End = &start, &end
+		today := midnight(time.Now().In(c.Timezone))
+		tomorrow := today.AddDate(0, 0, 1)
 
-		err = cal.Parse()
+		events, err := fetchEvents(c, today, tomorrow.AddDate(0, 0, 1))
 		if err != nil {
 			return calendar, err
 		}
 
-		for _, e := range cal.Events {
-			// Ignore all-day events from the previous day.
-			if e.Start.Before(today) {
-				continue
-			}
-
-			// Only consider events that match the attendees filter.
-			if !c.MatchesFilter(e) {
-				continue
-			}
-
+		for _, e := range events {
 			// Don't include a time for all day events.
 			var time time.Time
 			if e.Start.Hour() > 0 || e.Start.Minute() > 0 {
@@ -193,16 +188,55 @@ func fetchCalendars(options []CalendarOptions) (calendar, error) {
 	return calendar, nil
 }
 
+// fetchEvents returns the events of the given calendar that start between start (inclusive) and end, and that
+// match its attendees filter.
+func fetchEvents(c CalendarOptions, start, end time.Time) ([]gocal.Event, error) {
+	resp, err := http.Get(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// To catch all-day events, let them start one second before the start.
+	from := start.Add(-1 * time.Second)
+
+	cal := gocal.NewParser(resp.Body)
+	cal.AllDayEventsTZ = start.Location()
+	cal.Start, cal.End = &from, &end
+
+	if err := cal.Parse(); err != nil {
+		return nil, err
+	}
+
+	var events []gocal.Event
+	for _, e := range cal.Events {
+		// Ignore all-day events from the previous day.
+		if e.Start.Before(start) {
+			continue
+		}
+
+		// Only consider events that match the attendees filter.
+		if !c.MatchesFilter(e) {
+			continue
+		}
+		// Events come in the timezone of the calendar, or their own.
+		local := e.Start.In(c.Timezone)
+		e.Start = &local
+		events = append(events, e)
+	}
+	return events, nil
+}
+
 func (e event) String() string {
 	time := ""
 	// All-day events don't have a time, so will just show the summary.
 	if !e.Time.IsZero() {
-		time = e.Time.Local().Format("15h04 ")
+		time = e.Time.Format("15h04 ")
 	}
 	return time + e.Summary
 }
 
-func makeCalendarCards(getCalendar func() (calendar, error)) []Card {
+func makeCalendarCardsAndInfo(getCalendar func() (calendar, error)) ([]Card, CalendarInfo) {
 	return []Card{
 		{
 			Title:    "Aujourd'hui",
@@ -215,6 +249,7 @@ func makeCalendarCards(getCalendar func() (calendar, error)) []Card {
 
 				c.Body = ""
 				c.Items = []string{}
+				c.metrics = map[string]float64{}
 
 				if len(calendar.Today) == 0 {
 					return nil
@@ -225,6 +260,14 @@ func makeCalendarCards(getCalendar func() (calendar, error)) []Card {
 					c.Items = append(c.Items, e.String())
 				}
 
+				// All-day events don't have a time, so only timed events can be upcoming.
+				for _, e := range calendar.Today {
+					if !e.Time.IsZero() && e.Time.After(time.Now()) {
+						c.metrics[MetricHoursUntilFirstEvent] = time.Until(e.Time).Hours()
+						break
+					}
+				}
+
 				return nil
 			},
 		},
@@ -249,5 +292,22 @@ func makeCalendarCards(getCalendar func() (calendar, error)) []Card {
 				return nil
 			},
 		},
+	}, CalendarInfo{
+		loader: func(c *CalendarInfo) error {
+			calendar, err := getCalendar()
+			if err != nil {
+				return err
+			}
+
+			c.Today = []string{}
+			for _, e := range calendar.Today {
+				c.Today = append(c.Today, e.String())
+			}
+			c.Tomorrow = []string{}
+			for _, e := range calendar.Tomorrow {
+				c.Tomorrow = append(c.Tomorrow, e.String())
+			}
+			return nil
+		},
 	}
 }
@@ -8,10 +8,11 @@ import (
 type CardType int
 
 const (
-	CardTypeUnknown CardType = iota
-	CardTypeText             // Supports title, body, and footer.
-	CardTypeList             // Supports title, list, and footer.
-	CardTypeChart            // Supports title, chart, and footer.
+	CardTypeUnknown  CardType = iota
+	CardTypeText              // Supports title, body, and footer.
+	CardTypeList              // Supports title, list, and footer.
+	CardTypeChart             // Supports title, chart, and footer.
+	CardTypeForecast          // Supports title, forecast, and footer.
 )
 
 // Card represents a single information card to be displayed on the screen.
@@ -29,21 +30,54 @@ type Card struct {
 	// For CardTypeChart
 	Chart Chart
 
+	// For CardTypeForecast
+	Forecast []ForecastDay
+
 	Priority int
 
-	loader func(*Card) error
+	loader       func(*Card) error
+	visible      func() (bool, error) // Whether the Card should be displayed, evaluated before loading it.
+	hidden       bool
+	metrics      map[string]float64 // Metrics set by the loader, on top of those derived from the content.
+	priorities   []PriorityRuleOptions
+	basePriority *int // The priority before any loader or rule changed it, restored on every load.
 }
 
-// Load invokes the loader function to populate the Card's dynamic content.
+// Load invokes the loader function to populate the Card's dynamic content, then applies the priority rules.
+// The loader isn't invoked if the Card shouldn't be displayed.
 func (c *Card) Load() error {
+	// Start over from the base priority and no metrics, so that nothing carries over from a previous load.
+	if c.basePriority == nil {
+		base := c.Priority
+		c.basePriority = &base
+	}
+	c.Priority = *c.basePriority
+	c.metrics = nil
+
+	if c.visible != nil {
+		visible, err := c.visible()
+		if err != nil {
+			return err
+		}
+		c.hidden = !visible
+		if c.hidden {
+			return nil
+		}
+	}
 	if c.loader != nil {
-		return c.loader(c)
+		if err := c.loader(c); err != nil {
+			return err
+		}
 	}
+	c.prioritize()
 	return nil
 }
 
 // Returns whether a card is valid and should be displayed.
 func (c Card) Valid() bool {
+	if c.hidden {
+		return false
+	}
 	if c.Type == CardTypeText {
 		return len(c.Body) > 0
 	}
@@ -53,5 +87,16 @@ func (c Card) Valid() bool {
 	if c.Type == CardTypeChart {
 		return c.Chart.Valid()
 	}
+	if c.Type == CardTypeForecast {
+		return len(c.Forecast) > 0
+	}
 	return false
 }
+
+// ForecastDay holds the forecast for a single day of a CardTypeForecast Card.
+type ForecastDay struct {
+	Name           string
+	ConditionSVG   template.HTML
+	MaxTemperature int
+	MinTemperature int
+}
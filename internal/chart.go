@@ -1,14 +1,28 @@
 package internal
 
 import (
+	"fmt"
 	"slices"
+	"time"
 )
 
-// Chart represents a simple bar chart to be displayed on a Card.
+// ChartType represents how the data of a Chart is drawn.
+type ChartType int
+
+const (
+	ChartTypeBar  ChartType = iota // Bars shaded by value, shown when some data exceeds the minimum.
+	ChartTypeLine                  // Lines, always shown when there's data within the relevant hours.
+)
+
+// Chart represents a simple hourly chart to be displayed on a Card.
 type Chart struct {
-	Data    []int        // The points to graph on the chart.
-	Hours   HoursOptions // The relevant hours to display; high data outside this range is ignored.
-	Options ChartOptions // Chart display options.
+	Type        ChartType
+	Data        []int          // The points to graph on the chart.
+	Times       []time.Time    // The start of the hour of every point, or empty to use the index as the hour.
+	Secondary   []int          // Optional points to graph as a dashed line, for line charts.
+	Annotations map[int]string // Optional labels to add to some hours, keyed by local hour.
+	Hours       HoursOptions   // The relevant hours to display; high data outside this range is ignored.
+	Options     ChartOptions   // Chart display options.
 }
 
 type HoursOptions struct {
@@ -16,19 +30,54 @@ type HoursOptions struct {
 	End   int // Inclusive
 }
 
+// Includes returns whether the given local hour is within the range.
+func (h HoursOptions) Includes(hour int) bool {
+	return hour >= h.Start && hour <= h.End
+}
+
+// hourAt returns the local hour of the ith point of an hourly series, which isn't i on the days DST starts or ends.
+// Series without times are assumed to start at midnight.
+func hourAt(times []time.Time, i int) int {
+	if i < len(times) {
+		return times[i].Hour()
+	}
+	return i
+}
+
 func (c Chart) MaxValue() int {
 	return slices.Max(c.Data)
 }
 
+// RelevantMaxValue returns the max value within the relevant hours.
+func (c Chart) RelevantMaxValue() int {
+	highest, _ := relevantMax(c.Data, c.Times, c.Hours)
+	return highest
+}
+
+// Line returns whether the chart should be drawn with lines.
+func (c Chart) Line() bool {
+	return c.Type == ChartTypeLine
+}
+
+// Label returns the label for the ith point, along with the annotation of its hour if any.
+func (c Chart) Label(i int) []string {
+	hour := hourAt(c.Times, i)
+	label := []string{fmt.Sprintf("%dh", hour)}
+	if annotation, ok := c.Annotations[hour]; ok {
+		label = append(label, annotation)
+	}
+	return label
+}
+
 func (c Chart) Valid() bool {
 	if len(c.Data) == 0 {
 		return false
 	}
-	for hour, value := range c.Data {
-		if hour < c.Hours.Start || hour > c.Hours.End {
+	for i, value := range c.Data {
+		if !c.Hours.Includes(hourAt(c.Times, i)) {
 			continue
 		}
-		if value > c.Options.Min {
+		if c.Type == ChartTypeLine || value > c.Options.Min {
 			return true
 		}
 	}
@@ -53,3 +102,31 @@ type ChartOptions struct {
 func (c ChartConfig) ToChartOptions() ChartOptions {
 	return ChartOptions(c)
 }
+
+// relevantMin returns the lowest value within the relevant hours, or false if there's none.
+func relevantMin(data []int, times []time.Time, hours HoursOptions) (int, bool) {
+	lowest, ok := 0, false
+	for i, value := range data {
+		if !hours.Includes(hourAt(times, i)) {
+			continue
+		}
+		if !ok || value < lowest {
+			lowest, ok = value, true
+		}
+	}
+	return lowest, ok
+}
+
+// relevantMax returns the highest value within the relevant hours, or false if there's none.
+func relevantMax(data []int, times []time.Time, hours HoursOptions) (int, bool) {
+	highest, ok := 0, false
+	for i, value := range data {
+		if !hours.Includes(hourAt(times, i)) {
+			continue
+		}
+		if !ok || value > highest {
+			highest, ok = value, true
+		}
+	}
+	return highest, ok
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+)
+
+// ClothingItemOptions holds a clothing item to recommend when all of its set conditions are met.
+type ClothingItemOptions struct {
+	Name string
+	Icon string
+	When ClothingWhen
+}
+
+func (c ClothingItem) ToClothingItemOptions() ClothingItemOptions {
+	return ClothingItemOptions(c)
+}
+
+// makeClothingCard creates a Card recommending what to wear given the forecast.
+func makeClothingCard(options WeatherOptions, getWeather func() (weatherData, error)) Card {
+	return Card{
+		Title:    "Que porter?",
+		Type:     CardTypeList,
+		Priority: 58,
+		loader: func(c *Card) error {
+			c.Items = []string{}
+			data, err := getWeather()
+			if err != nil {
+				return err
+			}
+
+			for _, item := range options.Clothing {
+				if !item.Recommended(data, options) {
+					continue
+				}
+				label := strings.TrimSpace(item.Icon + " " + item.Name)
+				if !slices.Contains(c.Items, label) {
+					c.Items = append(c.Items, label)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// Recommended returns whether all the set conditions of the item are met by the forecast.
+func (o ClothingItemOptions) Recommended(data weatherData, options WeatherOptions) bool {
+	when := o.When
+	if when.MinTemperatureBelow != nil && data.TemperatureToday.Min >= *when.MinTemperatureBelow {
+		return false
+	}
+	if when.MaxTemperatureBelow != nil && data.TemperatureToday.Max >= *when.MaxTemperatureBelow {
+		return false
+	}
+	if when.MaxTemperatureAbove != nil && data.TemperatureToday.Max <= *when.MaxTemperatureAbove {
+		return false
+	}
+	if when.ApparentTemperatureBelow != nil {
+		lowest, ok := relevantMin(data.HourlyApparentTemperatures, data.HourlyTimes, options.RelevantHours)
+		if !ok || lowest >= *when.ApparentTemperatureBelow {
+			return false
+		}
+	}
+	if when.PrecipitationAbove != nil {
+		highest, ok := relevantMax(data.HourlyPrecipitationProbabilities, data.HourlyTimes, options.RelevantHours)
+		if !ok || highest <= *when.PrecipitationAbove {
+			return false
+		}
+	}
+	if when.UVIndexAbove != nil && data.UVIndex <= *when.UVIndexAbove {
+		return false
+	}
+	if when.Rainfall && data.Rainfall <= options.MinRainfallThreshold {
+		return false
+	}
+	if when.Snowfall && data.Snowfall <= options.MinSnowfallThreshold {
+		return false
+	}
+	return true
+}
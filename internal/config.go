@@ -1,7 +1,9 @@
 package internal
 
 import (
+	"fmt"
 	"io"
+	"log"
 	"time"
 
 	"go.yaml.in/yaml/v4"
@@ -9,10 +11,28 @@ import (
 
 // Config holds the configuration for the application, parsed from a YAML file.
 type Config struct {
+	Units     Units      `yaml:"units"`    // Either metric or imperial, for the weather data and thresholds.
+	Timezone  string     `yaml:"timezone"` // An IANA timezone, e.g. America/Montreal, the system one if empty.
 	Calendars []Calendar `yaml:"calendars"`
 	Weather   Weather    `yaml:"weather"`
 	Picture   Picture    `yaml:"picture"`
 	Generated Generated  `yaml:"generated"`
+	Countdown Countdown  `yaml:"countdown"`
+	Birthdays Birthdays  `yaml:"birthdays"`
+	Holidays  Holidays   `yaml:"holidays"`
+	Sensors   []Sensor   `yaml:"sensors"`
+
+	// Settings for any card, keyed by the card title.
+	Cards map[string]CardConfig `yaml:"cards"`
+}
+
+// Sensor is read from a local JSON HTTP endpoint, and shown in the header.
+type Sensor struct {
+	Name       string `yaml:"name"`
+	URL        string `yaml:"url"`
+	Path       string `yaml:"path"`        // The dotted path to the value, with indices for arrays, e.g. sensors.0.temperature, and escaped dots within keys, e.g. pm2\.5_aqi.
+	Unit       string `yaml:"unit"`        // Appended to the value, e.g. °.
+	AirQuality string `yaml:"air_quality"` // The index of the value, e.g. us_aqi, to override the current hour of the air quality chart. Must be the configured one.
 }
 
 type Calendar struct {
@@ -21,12 +41,23 @@ type Calendar struct {
 }
 
 type Weather struct {
-	Location             Location       `yaml:"location"`
-	Precipitations       Precipitations `yaml:"precipitations"`
-	AirQuality           AirQuality     `yaml:"airquality"`
-	MinDiffThreshold     int            `yaml:"min_diff_threshold"`        // The minimum temperature difference between yesterday and today to display a message about it.
-	MinRainfallThreshold int            `yaml:"min_rainfall_threshold_mm"` // The minimum rainfall (mm) to display a message about it.
-	MinSnowfallThreshold int            `yaml:"min_snowfall_threshold_cm"` //// The minimum rainfall (cm) to display a message about it.
+	Location              Location       `yaml:"location"`
+	Providers             []string       `yaml:"providers"` // In order of preference, among open-meteo and met-norway.
+	Precipitations        Precipitations `yaml:"precipitations"`
+	AirQuality            AirQuality     `yaml:"airquality"`
+	Pollen                Pollen         `yaml:"pollen"`
+	Temperature           Temperature    `yaml:"temperature"`
+	Clothing              []ClothingItem `yaml:"clothing"`
+	Daylight              Daylight       `yaml:"daylight"`
+	Alerts                Alerts         `yaml:"alerts"`
+	Forecast              Forecast       `yaml:"forecast"`
+	Normals               Normals        `yaml:"normals"`
+	MinDiffThreshold      int            `yaml:"min_diff_threshold"`        // The minimum temperature difference between yesterday and today to display a message about it.
+	MinRainfallThreshold  float64        `yaml:"min_rainfall_threshold_mm"` // The minimum rainfall (mm, whatever the units) to display a message about it.
+	MinSnowfallThreshold  float64        `yaml:"min_snowfall_threshold_cm"` // The minimum snowfall (cm, whatever the units) to display a message about it.
+	MinGustsThreshold     int            `yaml:"min_gusts_threshold"`       // The minimum wind gusts (km/h, or mph with imperial units) within the relevant hours to display a message about them.
+	MinWindChillThreshold int            `yaml:"min_wind_chill_threshold"`  // The minimum difference between the air and apparent temperatures to display a message about the wind chill.
+	MinUVIndexThreshold   float64        `yaml:"min_uv_index_threshold"`    // The minimum UV index within the relevant hours to display a message about it.
 }
 
 type Location struct {
@@ -40,25 +71,169 @@ type Precipitations struct {
 }
 
 type AirQuality struct {
+	Index string          `yaml:"index"` // One of us_aqi, european_aqi, or aqhi. The chart follows its breakpoints.
 	Hours TimeRangeConfig `yaml:"relevant_time"`
-	Chart ChartConfig     `yaml:"chart"`
+	Chart *ChartConfig    `yaml:"chart"` // Deprecated: ignored since the chart follows the breakpoints of the index.
+}
+
+type Pollen struct {
+	Species  []string `yaml:"species"`   // Among alder, birch, olive, grass, mugwort, and ragweed.
+	MinLevel string   `yaml:"min_level"` // One of low, moderate, high, or very_high.
+	Months   []int    `yaml:"months"`    // The pollen season, e.g. [4, 5, 6, 7, 8, 9].
+}
+
+type Temperature struct {
+	Enabled   bool            `yaml:"enabled"`
+	Hours     TimeRangeConfig `yaml:"relevant_time"`
+	Departure time.Duration   `yaml:"departure"` // When the kids leave for school, zero to hide.
+	Pickup    time.Duration   `yaml:"pickup"`    // When the kids come back from school, zero to hide.
+}
+
+type Daylight struct {
+	Enabled bool            `yaml:"enabled"`
+	Hours   TimeRangeConfig `yaml:"relevant_time"` // e.g. the walk home, a note is added when the sun sets within.
+}
+
+type Normals struct {
+	Enabled          bool   `yaml:"enabled"`
+	Years            int    `yaml:"years"`              // The number of past years the normals are computed over.
+	CacheFile        string `yaml:"cache_file"`         // Where the normals are kept once fetched from the archive, kidscreen/normals.json in the user cache directory by default.
+	MinDiffThreshold int    `yaml:"min_diff_threshold"` // The minimum difference between today's max and the normal max to display a message about it.
+}
+
+type Forecast struct {
+	Days int `yaml:"days"` // The number of days after today, up to 5, zero (the default) to hide the forecast.
+}
+
+type Alerts struct {
+	FeedURL   string `yaml:"feed_url"`   // An Atom feed of CAP alerts, e.g. from the NWS or Environment Canada.
+	UserAgent string `yaml:"user_agent"` // The NWS requires one identifying the application and a contact.
+}
+
+// ClothingItem is recommended when all of its set conditions are met by today's forecast.
+// Many items with the same name can be used to recommend it under different conditions.
+type ClothingItem struct {
+	Name string       `yaml:"name"`
+	Icon string       `yaml:"icon"`
+	When ClothingWhen `yaml:"when"`
+}
+
+type ClothingWhen struct {
+	MinTemperatureBelow      *int     `yaml:"min_temperature_below"`
+	MaxTemperatureBelow      *int     `yaml:"max_temperature_below"`
+	MaxTemperatureAbove      *int     `yaml:"max_temperature_above"`
+	ApparentTemperatureBelow *int     `yaml:"apparent_temperature_below"`      // The lowest within the relevant hours.
+	PrecipitationAbove       *int     `yaml:"precipitation_probability_above"` // The highest within the relevant hours.
+	UVIndexAbove             *float64 `yaml:"uv_index_above"`
+	Rainfall                 bool     `yaml:"rainfall"` // When the rainfall exceeds the min threshold.
+	Snowfall                 bool     `yaml:"snowfall"` // When the snowfall exceeds the min threshold.
 }
 
 type Picture struct {
 	PageURL    string `yaml:"page_url"`
 	ImageXPath string `yaml:"image_xpath"`
 	LabelXPath string `yaml:"label_xpath"`
+	When       When   `yaml:"when"`
 }
 
 type Generated struct {
-	OpenAIAPIKey string          `yaml:"open_ai_api_key"`
-	Cards        []GeneratedCard `yaml:"cards"`
+	OpenAIAPIKey string           `yaml:"open_ai_api_key"`
+	LLM          LLM              `yaml:",inline"`  // For every card, unless overridden by the card.
+	Children     []string         `yaml:"children"` // Their names, for the prompts.
+	History      GeneratedHistory `yaml:"history"`
+	Blocklist    []string         `yaml:"blocklist"` // Words or phrases the texts must not use, regardless of case.
+	Cards        []GeneratedCard  `yaml:"cards"`
+}
+
+// GeneratedHistory keeps the recent texts of every generated card, so they're not repeated.
+type GeneratedHistory struct {
+	File          string  `yaml:"file"`           // Empty to keep nothing.
+	Days          int     `yaml:"days"`           // How long the texts are kept, and not to be repeated.
+	MaxSimilarity float64 `yaml:"max_similarity"` // The share of words found in a recent text to generate it again.
 }
 
 type GeneratedCard struct {
-	Title    string `yaml:"title"`
-	Prompt   string `yaml:"prompt"`
-	Priority int    `yaml:"priority"`
+	Title     string `yaml:"title"`
+	Prompt    string `yaml:"prompt"` // A Go template, see promptData for the available data.
+	Priority  int    `yaml:"priority"`
+	When      When   `yaml:"when"`
+	LLM       LLM    `yaml:",inline"`
+	MaxWords  int    `yaml:"max_words"`  // The text is generated again when longer, and truncated as a last resort.
+	MaxChars  int    `yaml:"max_chars"`  // Likewise.
+	AllowHTML bool   `yaml:"allow_html"` // Whether the text is displayed as HTML, rather than escaped.
+}
+
+// LLM selects the model generating the cards, from OpenAI or any server with an OpenAI-compatible API.
+type LLM struct {
+	BaseURL     string   `yaml:"base_url"` // E.g. http://localhost:11434/v1 for Ollama, empty for OpenAI.
+	Model       string   `yaml:"model"`
+	Temperature *float64 `yaml:"temperature"` // The default of the server if unset.
+	MaxTokens   int      `yaml:"max_tokens"`  // No limit if zero.
+}
+
+type Countdown struct {
+	Dates        []CountdownDate `yaml:"dates"`
+	EventsRegExp string          `yaml:"events_regexp"` // Calendar events whose summary or description match are counted down to.
+	Count        int             `yaml:"count"`         // The number of upcoming dates to display.
+	HorizonDays  int             `yaml:"horizon_days"`  // How many days ahead to look for calendar events.
+	Priority     int             `yaml:"priority"`      // The priority when the nearest date is far away.
+	MaxPriority  int             `yaml:"max_priority"`  // The priority on the day itself.
+	BoostDays    int             `yaml:"boost_days"`    // How many days ahead the priority starts rising.
+}
+
+type CountdownDate struct {
+	Name string `yaml:"name"`
+	Date string `yaml:"date"` // Either YYYY-MM-DD, or MM-DD for a date that repeats every year.
+}
+
+type Birthdays struct {
+	File       string `yaml:"file"`        // A vCard (.vcf) or CSV (.csv) contacts export.
+	NameColumn string `yaml:"name_column"` // The CSV column holding the name.
+	DateColumn string `yaml:"date_column"` // The CSV column holding the birthday.
+	Priority   int    `yaml:"priority"`
+}
+
+type Holidays struct {
+	Region string          `yaml:"region"` // e.g. CA-QC or US-WA, its country's holidays are included.
+	Custom []CustomHoliday `yaml:"custom"`
+}
+
+// CustomHoliday holds a holiday that isn't in the embedded rules, e.g. a pedagogical day or spring break.
+type CustomHoliday struct {
+	Name       string `yaml:"name"`
+	Date       string `yaml:"date"`        // Either YYYY-MM-DD, or MM-DD for a date that repeats every year.
+	End        string `yaml:"end"`         // Optional last day, inclusive, for holidays spanning many days.
+	SchoolOpen bool   `yaml:"school_open"` // Whether schools stay open, e.g. for Halloween.
+}
+
+type CardConfig struct {
+	When     When           `yaml:"when"`
+	Priority []PriorityRule `yaml:"priority"` // The first matching rule overrides the card priority.
+}
+
+// PriorityRule sets the priority of a card when one of its metrics is within bounds.
+type PriorityRule struct {
+	Metric   string   `yaml:"metric"` // One of max_value, items, or hours_until_first_event.
+	Above    *float64 `yaml:"above"`  // Exclusive.
+	Below    *float64 `yaml:"below"`  // Exclusive.
+	Priority int      `yaml:"priority"`
+}
+
+// When holds the conditions under which a card is displayed. All the set conditions must be met.
+type When struct {
+	SchoolDays          bool             `yaml:"school_days"`           // Only display on school days.
+	Weekdays            []string         `yaml:"weekdays"`              // e.g. [monday, friday]
+	Months              []int            `yaml:"months"`                // e.g. [6, 7, 8]
+	Dates               []DateRange      `yaml:"dates"`                 // Only display within one of these ranges.
+	Time                *TimeRangeConfig `yaml:"time"`                  // Only display when rendered within this time.
+	TomorrowHasEvents   bool             `yaml:"tomorrow_has_events"`   // Only display if there are events tomorrow.
+	MaxTemperatureBelow *int             `yaml:"max_temperature_below"` // Only display if today's max is below.
+	MaxTemperatureAbove *int             `yaml:"max_temperature_above"` // Only display if today's max is above.
+}
+
+type DateRange struct {
+	Start string `yaml:"start"` // Either YYYY-MM-DD, or MM-DD for a date that repeats every year.
+	End   string `yaml:"end"`   // Inclusive, in the same format as the start.
 }
 
 type TimeRangeConfig struct {
@@ -73,9 +248,14 @@ type ChartConfig struct {
 	High int `yaml:"high"` // The value of maximum shade on the chart.
 }
 
-func defaultConfig() Config {
+func defaultConfig(units Units) Config {
+	if units == UnitsImperial {
+		return defaultImperialConfig()
+	}
 	return Config{
+		Units: UnitsMetric,
 		Weather: Weather{
+			Providers: []string{"open-meteo"},
 			Precipitations: Precipitations{
 				Hours: TimeRangeConfig{
 					Start: 7 * time.Hour,
@@ -89,36 +269,127 @@ func defaultConfig() Config {
 				},
 			},
 			AirQuality: AirQuality{
+				Index: string(AirQualityIndexUS),
 				Hours: TimeRangeConfig{
 					Start: 7 * time.Hour,
 					End:   20 * time.Hour,
 				},
-				Chart: ChartConfig{
-					Top:  100,
-					Step: 25,
-					Min:  45,
-					High: 100,
+			},
+			Pollen: Pollen{
+				MinLevel: "moderate",
+				Months:   []int{3, 4, 5, 6, 7, 8, 9},
+			},
+			Temperature: Temperature{
+				Hours: TimeRangeConfig{
+					Start: 7 * time.Hour,
+					End:   20 * time.Hour,
 				},
+				Departure: 8 * time.Hour,
+				Pickup:    15 * time.Hour,
 			},
-			MinDiffThreshold:     7,
-			MinRainfallThreshold: 5,
-			MinSnowfallThreshold: 5,
+			Daylight: Daylight{
+				Hours: TimeRangeConfig{
+					Start: 15 * time.Hour,
+					End:   18 * time.Hour,
+				},
+			},
+			Normals: Normals{
+				Years:            30,
+				MinDiffThreshold: 5,
+			},
+			Alerts: Alerts{
+				UserAgent: "kidscreen github.com/albertb/kidscreen",
+			},
+			MinDiffThreshold:      7,
+			MinRainfallThreshold:  5,
+			MinSnowfallThreshold:  5,
+			MinGustsThreshold:     50,
+			MinWindChillThreshold: 7,
+			MinUVIndexThreshold:   6,
+		},
+		Generated: Generated{
+			LLM: LLM{
+				Model: "gpt-4o",
+			},
+			History: GeneratedHistory{
+				Days:          30,
+				MaxSimilarity: 0.5,
+			},
+		},
+		Countdown: Countdown{
+			Count:       3,
+			HorizonDays: 90,
+			Priority:    30,
+			MaxPriority: 90,
+			BoostDays:   7,
+		},
+		Birthdays: Birthdays{
+			NameColumn: "Name",
+			DateColumn: "Birthday",
+			Priority:   80,
 		},
 	}
 }
 
+// defaultImperialConfig returns the metric default config, with only the thresholds following the units converted to
+// imperial units.
+func defaultImperialConfig() Config {
+	config := defaultConfig(UnitsMetric)
+	config.Units = UnitsImperial
+	config.Weather.MinDiffThreshold = 12
+	config.Weather.MinGustsThreshold = 30
+	config.Weather.MinWindChillThreshold = 12
+	config.Weather.Normals.MinDiffThreshold = 9
+	return config
+}
+
 func ReadConfig(reader io.Reader) (Config, error) {
-	config := defaultConfig()
-	if err := yaml.NewDecoder(reader).Decode(&config); err != nil {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return Config{}, err
+	}
+
+	// The defaults depend on the units, so find out about them first.
+	var units struct {
+		Units Units `yaml:"units"`
+	}
+	if err := yaml.Unmarshal(data, &units); err != nil {
+		return Config{}, err
+	}
+
+	config := defaultConfig(units.Units)
+	if err := yaml.Unmarshal(data, &config); err != nil {
 		return config, err
 	}
 	if err := config.validate(); err != nil {
 		return config, err
 	}
+	config.warnDeprecated()
 	return config, nil
 }
 
+// warnDeprecated logs the settings which are still accepted, but no longer have any effect.
+func (c Config) warnDeprecated() {
+	if c.Weather.AirQuality.Chart != nil {
+		log.Println("weather.airquality.chart is deprecated and ignored, the chart follows the breakpoints of the index")
+	}
+}
+
 func (c Config) validate() error {
+	if c.Units != UnitsMetric && c.Units != UnitsImperial {
+		return fmt.Errorf("unknown units: %s", c.Units)
+	}
+	if _, err := c.GetTimezone(); err != nil {
+		return err
+	}
+	if c.Weather.Forecast.Days < 0 || c.Weather.Forecast.Days > maxForecastDays {
+		return fmt.Errorf("forecast days must be between 0 and %d: %d", maxForecastDays, c.Weather.Forecast.Days)
+	}
+	// Any text is at least 0 similar to another, so every text would be a repeat.
+	if history := c.Generated.History; history.MaxSimilarity <= 0 || history.MaxSimilarity > 1 {
+		return fmt.Errorf("generated history max similarity must be above 0, and at most 1: %v",
+			history.MaxSimilarity)
+	}
 	// TODO
 	return nil
 }
@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CountdownOptions holds options for creating the countdown Card.
+// Events can be nil to only count down to the configured dates.
+type CountdownOptions struct {
+	Dates       []CountdownDateOptions
+	Calendars   []CalendarOptions
+	Events      *regexp.Regexp // Calendar events matching this are counted down to.
+	Count       int            // The number of upcoming dates to display.
+	HorizonDays int            // How many days ahead to look for calendar events.
+	Priority    int            // The priority when the nearest date is far away.
+	MaxPriority int            // The priority on the day itself.
+	BoostDays   int            // How many days ahead the priority starts rising.
+	Timezone    *time.Location // The days are counted in this timezone.
+}
+
+// CountdownDateOptions holds a single date to count down to.
+type CountdownDateOptions struct {
+	Name string
+	Date DateOptions
+}
+
+func (c Config) GetCountdownOptions() (CountdownOptions, error) {
+	timezone, err := c.GetTimezone()
+	if err != nil {
+		return CountdownOptions{}, err
+	}
+	options := CountdownOptions{
+		Count:       c.Countdown.Count,
+		HorizonDays: c.Countdown.HorizonDays,
+		Priority:    c.Countdown.Priority,
+		MaxPriority: c.Countdown.MaxPriority,
+		BoostDays:   c.Countdown.BoostDays,
+		Timezone:    timezone,
+	}
+
+	for _, d := range c.Countdown.Dates {
+		date, err := parseDate(d.Date)
+		if err != nil {
+			return options, fmt.Errorf("failed to parse countdown date (%s): %w", d.Name, err)
+		}
+		options.Dates = append(options.Dates, CountdownDateOptions{Name: d.Name, Date: date})
+	}
+
+	if len(c.Countdown.EventsRegExp) != 0 {
+		options.Events, err = regexp.Compile(c.Countdown.EventsRegExp)
+		if err != nil {
+			return options, fmt.Errorf("failed to compile regex: %w", err)
+		}
+		options.Calendars, err = c.GetCalendarOptions()
+		if err != nil {
+			return options, err
+		}
+	}
+	return options, nil
+}
+
+// NewCountdownCard creates a new countdown Card using the given options.
+// The card will display the nearest upcoming dates, and rise in priority as the first one approaches.
+func NewCountdownCard(options CountdownOptions) Card {
+	var once sync.Once
+	var dates []countdownDate
+	var err error
+
+	if len(options.Dates) == 0 && options.Events == nil {
+		return Card{}
+	}
+
+	return makeCountdownCard(options, func() ([]countdownDate, error) {
+		once.Do(func() {
+			dates, err = fetchCountdownDates(options)
+		})
+		return dates, err
+	})
+}
+
+// NewFakeCountdownCard creates a new countdown Card with fake data for testing purposes.
+func NewFakeCountdownCard(options CountdownOptions) Card {
+	return makeCountdownCard(options, func() ([]countdownDate, error) {
+		today := midnight(time.Now().In(options.Timezone))
+		return []countdownDate{
+			{Name: "Fête de Julie", Date: today.AddDate(0, 0, 3)},
+			{Name: "Voyage à la mer", Date: today.AddDate(0, 0, 12)},
+			{Name: "Noël", Date: DateOptions{Month: time.December, Day: 25}.Next(today)},
+		}, nil
+	})
+}
+
+type countdownDate struct {
+	Name string
+	Date time.Time
+}
+
+func fetchCountdownDates(options CountdownOptions) ([]countdownDate, error) {
+	var dates []countdownDate
+
+	today := midnight(time.Now().In(options.Timezone))
+	for _, d := range options.Dates {
+		dates = append(dates, countdownDate{Name: d.Name, Date: d.Date.Next(today)})
+	}
+
+	if options.Events != nil {
+		end := today.AddDate(0, 0, options.HorizonDays+1)
+		for _, c := range options.Calendars {
+			events, err := fetchEvents(c, today, end)
+			if err != nil {
+				return dates, err
+			}
+			for _, e := range events {
+				if !options.Events.MatchString(e.Summary) && !options.Events.MatchString(e.Description) {
+					continue
+				}
+				dates = append(dates, countdownDate{Name: e.Summary, Date: midnight(*e.Start)})
+			}
+		}
+	}
+
+	return dates, nil
+}
+
+func makeCountdownCard(options CountdownOptions, getDates func() ([]countdownDate, error)) Card {
+	return Card{
+		Title:    "Compte à rebours",
+		Type:     CardTypeList,
+		Priority: options.Priority,
+		loader: func(c *Card) error {
+			c.Items = []string{}
+			dates, err := getDates()
+			if err != nil {
+				return err
+			}
+
+			today := midnight(time.Now().In(options.Timezone))
+			upcoming := slices.DeleteFunc(slices.Clone(dates), func(d countdownDate) bool {
+				return d.Date.Before(today)
+			})
+			slices.SortFunc(upcoming, func(a, b countdownDate) int {
+				return cmp.Compare(a.Date.Unix(), b.Date.Unix())
+			})
+			if len(upcoming) > options.Count {
+				upcoming = upcoming[:options.Count]
+			}
+			if len(upcoming) == 0 {
+				return nil
+			}
+
+			for _, d := range upcoming {
+				c.Items = append(c.Items, d.String(today))
+			}
+			c.Priority = options.priority(daysBetween(today, upcoming[0].Date))
+			return nil
+		},
+	}
+}
+
+// priority returns the card priority when the nearest date is the given number of days away.
+func (o CountdownOptions) priority(days int) int {
+	if days >= o.BoostDays {
+		return o.Priority
+	}
+	return o.MaxPriority - (o.MaxPriority-o.Priority)*days/o.BoostDays
+}
+
+func (d countdownDate) String(today time.Time) string {
+	switch days := daysBetween(today, d.Date); days {
+	case 0:
+		return fmt.Sprintf("%s aujourd'hui!", d.Name)
+	case 1:
+		return fmt.Sprintf("%s demain", d.Name)
+	default:
+		return fmt.Sprintf("%s dans %d jours", d.Name, days)
+	}
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountdownPriority(t *testing.T) {
+	options := CountdownOptions{Priority: 30, MaxPriority: 90, BoostDays: 7}
+	for _, test := range []struct {
+		days int
+		want int
+	}{
+		{0, 90},
+		{1, 82},
+		{3, 65},
+		{6, 39},
+		{7, 30},
+		{90, 30},
+	} {
+		if got := options.priority(test.days); got != test.want {
+			t.Errorf("got priority %d %d days ahead, want %d", got, test.days, test.want)
+		}
+	}
+
+	// Without boost, the priority never rises.
+	options.BoostDays = 0
+	if got := options.priority(0); got != 30 {
+		t.Errorf("got priority %d on the day without boost, want 30", got)
+	}
+}
+
+func TestCountdownDateString(t *testing.T) {
+	montreal := loadTestLocation(t, "America/Montreal")
+	// DST starts on 2025-03-09, the day is only 23 hours long.
+	today := time.Date(2025, time.March, 8, 0, 0, 0, 0, montreal)
+	for _, test := range []struct {
+		date time.Time
+		want string
+	}{
+		{today, "Fête aujourd'hui!"},
+		{today.AddDate(0, 0, 1), "Fête demain"},
+		{today.AddDate(0, 0, 2), "Fête dans 2 jours"},
+		{today.AddDate(0, 0, 12), "Fête dans 12 jours"},
+	} {
+		if got := (countdownDate{Name: "Fête", Date: test.date}).String(today); got != test.want {
+			t.Errorf("got %s for %v, want %s", got, test.date, test.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// DateOptions holds a date, which can repeat every year.
+type DateOptions struct {
+	Month time.Month
+	Day   int
+	Year  int // Zero for dates that repeat every year.
+}
+
+// parseDate parses a date formatted as either YYYY-MM-DD, or MM-DD for a date that repeats every year.
+func parseDate(s string) (DateOptions, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return DateOptions{Year: t.Year(), Month: t.Month(), Day: t.Day()}, nil
+	}
+	t, err := time.Parse("01-02", s)
+	if err != nil {
+		return DateOptions{}, fmt.Errorf("expected YYYY-MM-DD or MM-DD, got %q", s)
+	}
+	return DateOptions{Month: t.Month(), Day: t.Day()}, nil
+}
+
+// Next returns the next occurrence of the date, on or after the given day.
+func (d DateOptions) Next(today time.Time) time.Time {
+	if d.Year != 0 {
+		return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, today.Location())
+	}
+	next := time.Date(today.Year(), d.Month, d.Day, 0, 0, 0, 0, today.Location())
+	if next.Before(today) {
+		next = next.AddDate(1, 0, 0)
+	}
+	return next
+}
+
+// DateRangeOptions holds a range of dates, which can repeat every year.
+type DateRangeOptions struct {
+	Start DateOptions
+	End   DateOptions // Inclusive.
+}
+
+func (c DateRange) ToDateRangeOptions() (DateRangeOptions, error) {
+	var options DateRangeOptions
+	var err error
+
+	options.Start, err = parseDate(c.Start)
+	if err != nil {
+		return options, err
+	}
+	options.End = options.Start
+	if c.End != "" {
+		options.End, err = parseDate(c.End)
+		if err != nil {
+			return options, err
+		}
+	}
+	if (options.Start.Year == 0) != (options.End.Year == 0) {
+		return options, fmt.Errorf("date range %s to %s mixes a yearly date with a dated one", c.Start, c.End)
+	}
+	return options, nil
+}
+
+// Includes returns whether the given day falls within the range.
+func (r DateRangeOptions) Includes(day time.Time) bool {
+	day = midnight(day)
+	if r.Start.Year != 0 {
+		start := time.Date(r.Start.Year, r.Start.Month, r.Start.Day, 0, 0, 0, 0, day.Location())
+		end := time.Date(r.End.Year, r.End.Month, r.End.Day, 0, 0, 0, 0, day.Location())
+		return !day.Before(start) && !day.After(end)
+	}
+
+	// For yearly ranges, find the occurrence that started most recently, which can be last year for ranges
+	// spanning the new year.
+	start := r.Start.Next(day.AddDate(-1, 0, 1))
+	end := r.End.Next(start)
+	return !day.After(end)
+}
+
+// midnight returns the start of the day of the given time.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween returns the number of calendar days between two midnights, ignoring DST shifts.
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
+
+// hoursOfDay returns the start of every hour of the day of the given time, which can be 23 or 25 of them on the days
+// DST starts or ends.
+func hoursOfDay(t time.Time) []time.Time {
+	var hours []time.Time
+	end := midnight(t).AddDate(0, 0, 1)
+	for hour := midnight(t); hour.Before(end); hour = hour.Add(time.Hour) {
+		hours = append(hours, hour)
+	}
+	return hours
+}
+
+// sinceMidnight returns the wall clock time of the given time, e.g. 8h for 8:00 even on the days DST starts or ends.
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+}
+
+// GetTimezone returns the configured timezone, or the system one if none is configured.
+func (c Config) GetTimezone() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone: %s", c.Timezone)
+	}
+	return location, nil
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// DaylightOptions holds options for creating the daylight Card.
+type DaylightOptions struct {
+	Enabled       bool
+	Location      LatLng
+	RelevantHours TimeRangeConfig // A note is added when the sun sets within these hours.
+	Timezone      *time.Location  // The sunrise and sunset are given in this timezone.
+}
+
+func (c Config) GetDaylightOptions() (DaylightOptions, error) {
+	timezone, err := c.GetTimezone()
+	if err != nil {
+		return DaylightOptions{}, err
+	}
+	return DaylightOptions{
+		Enabled:       c.Weather.Daylight.Enabled,
+		Location:      LatLng(c.Weather.Location),
+		RelevantHours: c.Weather.Daylight.Hours,
+		Timezone:      timezone,
+	}, nil
+}
+
+// NewDaylightCard creates a new Card with today's sunrise, sunset and day length, computed from the location.
+func NewDaylightCard(options DaylightOptions) Card {
+	return makeDaylightCard(options, func() time.Time {
+		return time.Now().In(options.Timezone)
+	})
+}
+
+// NewFakeDaylightCard creates a new daylight Card for a random day for testing purposes.
+func NewFakeDaylightCard(options DaylightOptions) Card {
+	return makeDaylightCard(options, func() time.Time {
+		return time.Now().In(options.Timezone).Add(24 * time.Hour * time.Duration(rand.Intn(364)))
+	})
+}
+
+func makeDaylightCard(options DaylightOptions, getTime func() time.Time) Card {
+	if !options.Enabled {
+		return Card{}
+	}
+
+	return Card{
+		Title:    "Lumière du jour",
+		Type:     CardTypeText,
+		Priority: 40,
+		loader: func(c *Card) error {
+			c.Body = ""
+			now := getTime()
+
+			sunrise, sunset, ok := sunTimes(now, options.Location)
+			if !ok {
+				// No sunrise or sunset near the poles, nothing worth displaying.
+				return nil
+			}
+
+			sb := strings.Builder{}
+			sb.WriteString(fmt.Sprintf("Lever: %s<br>", sunrise.Format("15h04")))
+			sb.WriteString(fmt.Sprintf("Coucher: %s<br>", sunset.Format("15h04")))
+
+			length := sunset.Sub(sunrise)
+			sb.WriteString(fmt.Sprintf("%s de clarté", formatDuration(length)))
+			if yesterdaySunrise, yesterdaySunset, ok := sunTimes(now.AddDate(0, 0, -1), options.Location); ok {
+				diff := int(math.Round((length - yesterdaySunset.Sub(yesterdaySunrise)).Minutes()))
+				if diff > 0 {
+					sb.WriteString(fmt.Sprintf(" (%d min de plus qu'hier)", diff))
+				} else if diff < 0 {
+					sb.WriteString(fmt.Sprintf(" (%d min de moins qu'hier)", -diff))
+				}
+			}
+
+			since := sinceMidnight(sunset)
+			if since >= options.RelevantHours.Start && since <= options.RelevantHours.End {
+				sb.WriteString("<br>Il fera noir au retour, allumez les lumières!")
+			}
+
+			c.Body = template.HTML(sb.String())
+			return nil
+		},
+	}
+}
+
+// formatDuration formats a duration in hours and minutes, e.g. 9h05.
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	return fmt.Sprintf("%dh%02d", minutes/60, minutes%60)
+}
+
+// sunTimes returns the times of the sunrise and sunset on the day of t, in the location of t, using the sunrise
+// equation. It returns false when the sun doesn't rise or set that day.
+// See https://en.wikipedia.org/wiki/Sunrise_equation for the details.
+func sunTimes(t time.Time, location LatLng) (time.Time, time.Time, bool) {
+	const epoch = 2451545.0 // The Julian date of 2000-01-01 12:00 UTC.
+	radians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	degrees := func(radians float64) float64 { return radians * 180 / math.Pi }
+
+	// Days since the epoch, for noon UTC on the same calendar day.
+	noon := time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(noon.Unix())/86400 + 2440587.5 - epoch)
+
+	// Mean solar time, solar mean anomaly, equation of the center and ecliptic longitude.
+	j := n - float64(location.Lng)/360
+	m := math.Mod(357.5291+0.98560028*j, 360)
+	c := 1.9148*math.Sin(radians(m)) + 0.02*math.Sin(radians(2*m)) + 0.0003*math.Sin(radians(3*m))
+	lambda := math.Mod(m+c+180+102.9372, 360)
+	transit := epoch + j + 0.0053*math.Sin(radians(m)) - 0.0069*math.Sin(radians(2*lambda))
+
+	// Declination of the sun and hour angle, with the refraction and the size of the sun's disc.
+	declination := math.Asin(math.Sin(radians(lambda)) * math.Sin(radians(23.4397)))
+	latitude := radians(float64(location.Lat))
+	cos := (math.Sin(radians(-0.833)) - math.Sin(latitude)*math.Sin(declination)) /
+		(math.Cos(latitude) * math.Cos(declination))
+	if cos < -1 || cos > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	omega := degrees(math.Acos(cos))
+
+	toTime := func(julian float64) time.Time {
+		seconds := (julian - 2440587.5) * 86400
+		return time.Unix(int64(math.Round(seconds)), 0).In(t.Location())
+	}
+	return toTime(transit - omega/360), toTime(transit + omega/360), true
+}
@@ -2,11 +2,18 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
+	"log"
 	"math/rand"
+	"slices"
+	"strings"
 	"sync"
+	texttemplate "text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -15,54 +22,220 @@ import (
 // GeneratedOptions holds options for creating LLM-generated Cards.
 type GeneratedOptions struct {
 	OpenAIAPIKey string
+	Children     []string
+	History      HistoryOptions
+	Blocklist    []string // Words or phrases the texts must not use, regardless of case.
 	Cards        []GeneratedCardOptions
 }
 
 // GeneratedCardOptions holds options for creating a single LLM-generated Card.
 type GeneratedCardOptions struct {
-	Title    string
-	Prompt   string
-	Priority int
+	Title     string
+	Prompt    *texttemplate.Template // Executed with a promptData.
+	Priority  int
+	When      WhenOptions
+	LLM       LLMOptions
+	MaxWords  int  // Zero for no max.
+	MaxChars  int  // Zero for no max.
+	AllowHTML bool // Whether the text is displayed as HTML, rather than escaped.
 }
 
-func (c Config) GetGeneratedOptions() GeneratedOptions {
-	return GeneratedOptions{
+// LLMOptions holds options for requesting completions from an OpenAI-compatible API.
+type LLMOptions struct {
+	BaseURL     string // Empty for OpenAI.
+	Model       string
+	Temperature *float64 // Nil for the default of the server.
+	MaxTokens   int      // Zero for no limit.
+}
+
+// ToLLMOptions returns the options of the LLM, with the unset ones taken from the given defaults.
+func (c LLM) ToLLMOptions(defaults LLM) LLMOptions {
+	options := LLMOptions(defaults)
+	if c.BaseURL != "" {
+		options.BaseURL = c.BaseURL
+	}
+	if c.Model != "" {
+		options.Model = c.Model
+	}
+	if c.Temperature != nil {
+		options.Temperature = c.Temperature
+	}
+	if c.MaxTokens != 0 {
+		options.MaxTokens = c.MaxTokens
+	}
+	return options
+}
+
+func (c Config) GetGeneratedOptions() (GeneratedOptions, error) {
+	options := GeneratedOptions{
 		OpenAIAPIKey: c.Generated.OpenAIAPIKey,
-		Cards: func() []GeneratedCardOptions {
-			var cards []GeneratedCardOptions
-			for _, card := range c.Generated.Cards {
-				cards = append(cards, GeneratedCardOptions(card))
-			}
-			return cards
-		}(),
+		Children:     c.Generated.Children,
+		History:      HistoryOptions(c.Generated.History),
+		Blocklist:    c.Generated.Blocklist,
 	}
+	for _, card := range c.Generated.Cards {
+		when, err := card.When.ToWhenOptions()
+		if err != nil {
+			return options, fmt.Errorf("invalid conditions for generated card (%s): %w", card.Title, err)
+		}
+		prompt, err := texttemplate.New(card.Title).Funcs(promptFuncs).Parse(card.Prompt)
+		if err != nil {
+			return options, fmt.Errorf("invalid prompt for generated card (%s): %w", card.Title, err)
+		}
+		llm := card.LLM.ToLLMOptions(c.Generated.LLM)
+		if llm.Model == "" {
+			return options, fmt.Errorf("no model for generated card (%s)", card.Title)
+		}
+		options.Cards = append(options.Cards, GeneratedCardOptions{
+			Title:     card.Title,
+			Prompt:    prompt,
+			Priority:  card.Priority,
+			When:      when,
+			LLM:       llm,
+			MaxWords:  card.MaxWords,
+			MaxChars:  card.MaxChars,
+			AllowHTML: card.AllowHTML,
+		})
+	}
+	return options, nil
 }
 
-// NewGeneratedCards creates new LLM-generated Cards using the given options.
-func NewGeneratedCards(options GeneratedOptions) []Card {
-	client := openai.NewClient(
-		option.WithAPIKey(options.OpenAIAPIKey),
-	)
+// promptData is given to the prompt templates. The weather, calendar, and holidays are loaded when the prompt uses
+// them, so the cards they come from are loaded first, e.g. {{if .Weather.Rain}}il va pleuvoir{{end}}. They're only
+// known for today, so prompts using them can't be generated ahead of time.
+type promptData struct {
+	Date     time.Time
+	Children []string
 
-	var cards []Card
+	env *WhenEnv
+}
+
+// errNotToday is returned when a prompt uses data only known for today to generate the text of another day.
+var errNotToday = errors.New("only known for today")
+
+func (d promptData) today() bool {
+	return midnight(d.Date).Equal(midnight(time.Now().In(d.Date.Location())))
+}
+
+func (d promptData) Weather() (WeatherInfo, error) {
+	if !d.today() {
+		return WeatherInfo{}, errNotToday
+	}
+	err := d.env.Weather.Load()
+	return d.env.Weather, err
+}
+
+func (d promptData) Calendar() (CalendarInfo, error) {
+	if !d.today() {
+		return CalendarInfo{}, errNotToday
+	}
+	err := d.env.Calendar.Load()
+	return d.env.Calendar, err
+}
+
+func (d promptData) Holidays() (HolidayInfo, error) {
+	if !d.today() {
+		return HolidayInfo{}, errNotToday
+	}
+	err := d.env.Holidays.Load()
+	return d.env.Holidays, err
+}
+
+var promptFuncs = texttemplate.FuncMap{
+	"join": strings.Join,
+}
+
+// cardGenerator generates the texts of a single card.
+type cardGenerator struct {
+	card      GeneratedCardOptions
+	client    openai.Client
+	children  []string
+	blocklist []string
+	env       WhenEnv
+	contexts  []GeneratedContext
+	store     *generatedStore
+}
+
+func newCardGenerators(options GeneratedOptions, env WhenEnv, contexts []GeneratedContext) []cardGenerator {
+	store := newGeneratedStore(options.History)
+
+	var generators []cardGenerator
 	for _, card := range options.Cards {
+		// Local servers don't need the API key, but don't mind it either.
+		requestOptions := []option.RequestOption{option.WithAPIKey(options.OpenAIAPIKey)}
+		if card.LLM.BaseURL != "" {
+			requestOptions = append(requestOptions, option.WithBaseURL(card.LLM.BaseURL))
+		}
+		generators = append(generators, cardGenerator{
+			card:      card,
+			client:    openai.NewClient(requestOptions...),
+			children:  options.Children,
+			blocklist: options.Blocklist,
+			env:       env,
+			contexts:  contexts,
+			store:     store,
+		})
+	}
+	return generators
+}
+
+// Prompt returns the prompt of the card for the given day.
+func (g cardGenerator) Prompt(day time.Time) (string, error) {
+	// Like the conditions, every card loads its own copy of the data.
+	env := g.env
+	var prompt strings.Builder
+	data := promptData{Date: day, Children: g.children, env: &env}
+	if err := g.card.Prompt.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("failed to execute prompt: %w", err)
+	}
+	return prompt.String(), nil
+}
+
+// NewGeneratedCards creates new LLM-generated Cards using the given options, with prompts using the data in env.
+// The optional contexts are given to the LLM along with the prompt of every card. The texts generated ahead of time
+// are used when there are some for today.
+func NewGeneratedCards(options GeneratedOptions, env WhenEnv, contexts ...GeneratedContext) []Card {
+	var cards []Card
+	for _, generator := range newCardGenerators(options, env, contexts) {
 		var once sync.Once
 		var body string
 		var err error
 
 		cards = append(cards, Card{
-			Title:    template.HTML(card.Title),
+			Title:    template.HTML(generator.card.Title),
 			Type:     CardTypeText,
-			Priority: card.Priority,
+			Priority: generator.card.Priority,
 			loader: func(c *Card) error {
 				c.Body = ""
 				once.Do(func() {
-					body, err = fetchCompletion(client, card.Prompt)
+					now := time.Now().In(env.Timezone)
+					var prompt string
+					prompt, err = generator.Prompt(now)
+					if err != nil {
+						return
+					}
+
+					// The history only avoids repeats, so the text is still displayed when it can't be used.
+					var ok bool
+					body, ok, err = generator.store.Get(generator.card.Title, now, prompt)
+					if err != nil {
+						log.Printf("failed to read generated history (%s): %v", generator.card.Title, err)
+					}
+					if ok {
+						return
+					}
+					body, err = generator.generate(now, prompt)
+					if err != nil {
+						return
+					}
+					if err := generator.store.Put(generator.card.Title, now, prompt, body); err != nil {
+						log.Printf("failed to keep generated text (%s): %v", generator.card.Title, err)
+					}
 				})
 				if err != nil {
 					return err
 				}
-				c.Body = template.HTML(body)
+				c.Body = generatedHTML(body, generator.card.AllowHTML)
 				return nil
 			},
 		})
@@ -70,6 +243,54 @@ func NewGeneratedCards(options GeneratedOptions) []Card {
 	return cards
 }
 
+// PregenerateCards generates the texts of the cards for today and the following days, up to the given number of days,
+// and keeps them for the renders. Texts already kept are left as is. Cards aren't generated for the days their
+// conditions on the date aren't met, nor for the days after today their prompts use data only known for today.
+func PregenerateCards(options GeneratedOptions, days int, env WhenEnv, contexts ...GeneratedContext) error {
+	if options.History.File == "" {
+		return fmt.Errorf("no history file to keep the generated texts in")
+	}
+
+	var errs error
+	today := midnight(time.Now().In(env.Timezone))
+	for _, generator := range newCardGenerators(options, env, contexts) {
+		for i := range days {
+			day := today.AddDate(0, 0, i)
+			if !generator.card.When.MetOn(day) {
+				continue
+			}
+			prompt, err := generator.Prompt(day)
+			if errors.Is(err, errNotToday) {
+				log.Printf("not generating ahead of time (%s) for %s: %v",
+					generator.card.Title, day.Format("2006-01-02"), err)
+				continue
+			}
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("failed to generate card (%s) for %s: %w",
+					generator.card.Title, day.Format("2006-01-02"), err))
+				continue
+			}
+			if _, ok, err := generator.store.Get(generator.card.Title, day, prompt); err != nil {
+				return err
+			} else if ok {
+				continue
+			}
+
+			text, err := generator.generate(day, prompt)
+			if err == nil {
+				err = generator.store.Put(generator.card.Title, day, prompt, text)
+			}
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("failed to generate card (%s) for %s: %w",
+					generator.card.Title, day.Format("2006-01-02"), err))
+				continue
+			}
+			log.Printf("generated card (%s) for %s: %s", generator.card.Title, day.Format("2006-01-02"), text)
+		}
+	}
+	return errs
+}
+
 // NewFakeGeneratedCards creates new Cards with hardcoded content for testing purposes.
 func NewFakeGeneratedCards() []Card {
 	blurbs := []string{
@@ -98,21 +319,163 @@ func NewFakeGeneratedCards() []Card {
 	}
 }
 
-func fetchCompletion(client openai.Client, prompt string) (string, error) {
+// maxGeneratedAttempts is how many times a text is generated before settling for one that doesn't meet all the
+// constraints of its card.
+const maxGeneratedAttempts = 3
+
+// generate generates the text of the card for the given day. When the text is too long, repeats a recent text of the
+// card, or uses a blocked word, the LLM is asked to fix it. Texts still too long after that are truncated, but texts
+// still using a blocked word are rejected.
+func (g cardGenerator) generate(day time.Time, prompt string) (string, error) {
+	recent, err := g.store.Recent(g.card.Title, day)
+	if err != nil {
+		log.Printf("failed to read generated history, repeats are possible (%s): %v", g.card.Title, err)
+	}
+	messages, err := completionMessages(day, prompt, recent, g.contexts)
+	if err != nil {
+		return "", err
+	}
+
+	var text string
+	for attempt := 1; ; attempt++ {
+		text, err = fetchCompletion(g.client, g.card.LLM, messages)
+		if err != nil {
+			return "", err
+		}
+		problem := g.check(text, recent)
+		if problem == "" {
+			break
+		}
+		if attempt == maxGeneratedAttempts {
+			if word, ok := blockedWord(text, g.blocklist); ok {
+				return "", fmt.Errorf("generated text uses a blocked word: %s", word)
+			}
+			text = truncate(text, g.card.MaxWords, g.card.MaxChars)
+			break
+		}
+		log.Printf("generated text rejected, retrying (%s): %s: %s", g.card.Title, problem, text)
+		messages = append(messages, openai.AssistantMessage(text), openai.UserMessage(problem))
+	}
+	return text, nil
+}
+
+// check returns the feedback to give to the LLM when the text doesn't meet the constraints of the card, or an empty
+// string if it does.
+func (g cardGenerator) check(text string, recent []string) string {
+	var problems []string
+	if words := len(strings.Fields(text)); g.card.MaxWords > 0 && words > g.card.MaxWords {
+		problems = append(problems, fmt.Sprintf("It has %d words, but must have at most %d.", words, g.card.MaxWords))
+	}
+	if chars := utf8.RuneCountInString(text); g.card.MaxChars > 0 && chars > g.card.MaxChars {
+		problems = append(problems, fmt.Sprintf("It has %d characters, but must have at most %d.",
+			chars, g.card.MaxChars))
+	}
+	if g.store.Repeats(text, recent) {
+		problems = append(problems, "It repeats a previous answer, pick another subject.")
+	}
+	if word, ok := blockedWord(text, g.blocklist); ok {
+		problems = append(problems, fmt.Sprintf("It's for kids, don't use words like %q.", word))
+	}
+	if len(problems) == 0 {
+		return ""
+	}
+	return "Rewrite your answer. " + strings.Join(problems, " ")
+}
+
+// blockedWord returns the first entry of the blocklist found in the text, regardless of case, or false if there's
+// none. Entries of many words, e.g. "ta gueule" or "t'es nul", match the same words in a row, whatever the spaces
+// and punctuation between them.
+func blockedWord(text string, blocklist []string) (string, bool) {
+	words := splitWords(text)
+	for _, blocked := range blocklist {
+		phrase := splitWords(blocked)
+		if len(phrase) == 0 {
+			continue
+		}
+		for i := 0; i+len(phrase) <= len(words); i++ {
+			if slices.EqualFunc(words[i:i+len(phrase)], phrase, strings.EqualFold) {
+				return blocked, true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitWords splits the text into its words, made of letters, digits, and hyphens.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+}
+
+// truncate shortens the text to the max number of words and characters, zero for no max, ending it with an ellipsis
+// when shortened.
+func truncate(text string, maxWords, maxChars int) string {
+	if words := strings.Fields(text); maxWords > 0 && len(words) > maxWords {
+		text = strings.Join(words[:maxWords], " ") + "…"
+	}
+	if runes := []rune(text); maxChars > 0 && len(runes) > maxChars {
+		text = strings.TrimSpace(string(runes[:maxChars-1])) + "…"
+	}
+	return text
+}
+
+// generatedHTML returns the text to display on the card, escaped unless the card allows HTML.
+func generatedHTML(text string, allowHTML bool) template.HTML {
+	if allowHTML {
+		return template.HTML(text)
+	}
+	return template.HTML(template.HTMLEscapeString(text))
+}
+
+// completionMessages returns the messages asking for the completion of the prompt for the given day, without
+// repeating the previous texts.
+func completionMessages(
+	day time.Time, prompt string, previous []string, contexts []GeneratedContext,
+) ([]openai.ChatCompletionMessageParamUnion, error) {
+	messages := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage(fmt.Sprintf("The current date is %s", day.Format("January 2, 2006"))),
+	}
+	if len(previous) > 0 {
+		messages = append(messages, openai.SystemMessage(
+			"Don't repeat any of these previous answers, nor their subjects:\n- "+strings.Join(previous, "\n- "),
+		))
+	}
+	for _, getContext := range contexts {
+		extra, err := getContext(day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get context: %w", err)
+		}
+		if extra != "" {
+			messages = append(messages, openai.SystemMessage(extra))
+		}
+	}
+	return append(messages, openai.UserMessage(prompt)), nil
+}
+
+func fetchCompletion(
+	client openai.Client, llm LLMOptions, messages []openai.ChatCompletionMessageParamUnion,
+) (string, error) {
 	var completion string
 
-	response, err := client.Chat.Completions.New(
-		context.Background(),
-		openai.ChatCompletionNewParams{
-			Messages: []openai.ChatCompletionMessageParamUnion{
-				openai.SystemMessage(fmt.Sprintf("The current date is %s", time.Now().Format("January 2, 2006"))),
-				openai.UserMessage(prompt),
-			},
-			Model: openai.ChatModelGPT4o,
-		},
-	)
+	params := openai.ChatCompletionNewParams{
+		Messages: messages,
+		Model:    llm.Model,
+	}
+	if llm.Temperature != nil {
+		params.Temperature = openai.Float(*llm.Temperature)
+	}
+	if llm.MaxTokens > 0 {
+		// Local servers don't all support max_completion_tokens yet.
+		params.MaxTokens = openai.Int(int64(llm.MaxTokens))
+	}
+
+	response, err := client.Chat.Completions.New(context.Background(), params)
 	if err != nil {
 		return completion, err
 	}
-	return response.Choices[0].Message.Content, nil
+	if len(response.Choices) == 0 {
+		return completion, fmt.Errorf("no completion returned by %s", llm.Model)
+	}
+	return strings.TrimSpace(response.Choices[0].Message.Content), nil
 }
@@ -0,0 +1,458 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// completionRequest is a chat completion request received by a completionServer.
+type completionRequest struct {
+	Path          string
+	Authorization string
+	Body          map[string]any
+}
+
+// completionServer is an OpenAI-compatible server answering the chat completions with the given texts in turn, the
+// last one repeated, and recording the requests.
+type completionServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []completionRequest
+}
+
+func newCompletionServer(t *testing.T, texts ...string) *completionServer {
+	t.Helper()
+	s := &completionServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, completionRequest{
+			Path:          r.URL.Path,
+			Authorization: r.Header.Get("Authorization"),
+			Body:          body,
+		})
+		text := texts[min(len(s.requests), len(texts))-1]
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"created": 0,
+			"model":   body["model"],
+			"choices": []map[string]any{{
+				"index":         0,
+				"message":       map[string]any{"role": "assistant", "content": text},
+				"finish_reason": "stop",
+			}},
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Requests returns the requests received so far.
+func (s *completionServer) Requests() []completionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]completionRequest(nil), s.requests...)
+}
+
+// readGeneratedOptions reads the config and returns the options of its generated cards.
+func readGeneratedOptions(t *testing.T, yaml string) GeneratedOptions {
+	t.Helper()
+	config, err := ReadConfig(strings.NewReader(yaml))
+	if err != nil {
+		t.Fatalf("ReadConfig() failed: %v", err)
+	}
+	options, err := config.GetGeneratedOptions()
+	if err != nil {
+		t.Fatalf("GetGeneratedOptions() failed: %v", err)
+	}
+	return options
+}
+
+// newTestGeneratedCards reads the config and creates its generated cards.
+func newTestGeneratedCards(t *testing.T, yaml string) []Card {
+	t.Helper()
+	return NewGeneratedCards(readGeneratedOptions(t, yaml), WhenEnv{Timezone: time.UTC})
+}
+
+// loadGeneratedCards reads the config and loads its generated cards.
+func loadGeneratedCards(t *testing.T, yaml string) []Card {
+	t.Helper()
+	cards := newTestGeneratedCards(t, yaml)
+	for i := range cards {
+		if err := cards[i].Load(); err != nil {
+			t.Fatalf("failed to load card (%s): %v", cards[i].Title, err)
+		}
+	}
+	return cards
+}
+
+func TestGeneratedCardsLLMOptions(t *testing.T) {
+	shared := newCompletionServer(t, "Bonjour!")
+	own := newCompletionServer(t, "Salut!")
+
+	cards := loadGeneratedCards(t, fmt.Sprintf(`
+generated:
+  open_ai_api_key: "secret"
+  base_url: "%s/v1"
+  model: llama3
+  temperature: 0.2
+  max_tokens: 50
+  cards:
+    - title: "Partagé"
+      prompt: "Dis bonjour."
+    - title: "Propre"
+      prompt: "Dis salut."
+      base_url: "%s/v1"
+      model: mistral
+      temperature: 0.9
+      max_tokens: 80
+`, shared.URL, own.URL))
+
+	if cards[0].Body != "Bonjour!" || cards[1].Body != "Salut!" {
+		t.Errorf("got bodies %q and %q, want the texts of their servers", cards[0].Body, cards[1].Body)
+	}
+
+	for _, test := range []struct {
+		name        string
+		server      *completionServer
+		model       string
+		temperature float64
+		maxTokens   float64
+	}{
+		{"shared settings", shared, "llama3", 0.2, 50},
+		{"card settings", own, "mistral", 0.9, 80},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			requests := test.server.Requests()
+			if len(requests) != 1 {
+				t.Fatalf("got %d requests, want 1", len(requests))
+			}
+			request := requests[0]
+			if request.Path != "/v1/chat/completions" {
+				t.Errorf("got path %s, want /v1/chat/completions under the base URL", request.Path)
+			}
+			if request.Authorization != "Bearer secret" {
+				t.Errorf("got authorization %q, want the API key", request.Authorization)
+			}
+			if got := request.Body["model"]; got != test.model {
+				t.Errorf("got model %v, want %s", got, test.model)
+			}
+			if got := request.Body["temperature"]; got != test.temperature {
+				t.Errorf("got temperature %v, want %v", got, test.temperature)
+			}
+			if got := request.Body["max_tokens"]; got != test.maxTokens {
+				t.Errorf("got max tokens %v, want %v", got, test.maxTokens)
+			}
+		})
+	}
+}
+
+func TestGeneratedCardsLLMDefaults(t *testing.T) {
+	config, err := ReadConfig(strings.NewReader(`
+generated:
+  cards:
+    - title: "Défaut"
+      prompt: "Dis bonjour."
+`))
+	if err != nil {
+		t.Fatalf("ReadConfig() failed: %v", err)
+	}
+	options, err := config.GetGeneratedOptions()
+	if err != nil {
+		t.Fatalf("GetGeneratedOptions() failed: %v", err)
+	}
+	if got := options.Cards[0].LLM; got != (LLMOptions{Model: "gpt-4o"}) {
+		t.Errorf("got %+v, want OpenAI's gpt-4o with the defaults of the server", got)
+	}
+
+	// The server is needed to check the request, the rest is left to the defaults.
+	server := newCompletionServer(t, "Bonjour!")
+	loadGeneratedCards(t, fmt.Sprintf(`
+generated:
+  base_url: "%s/v1"
+  cards:
+    - title: "Défaut"
+      prompt: "Dis bonjour."
+`, server.URL))
+
+	requests := server.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	body := requests[0].Body
+	if got := body["model"]; got != "gpt-4o" {
+		t.Errorf("got model %v, want gpt-4o", got)
+	}
+	for _, key := range []string{"temperature", "max_tokens", "max_completion_tokens"} {
+		if value, ok := body[key]; ok {
+			t.Errorf("got %s %v, want it left to the server", key, value)
+		}
+	}
+}
+
+func TestGeneratedCardsNoModel(t *testing.T) {
+	config, err := ReadConfig(strings.NewReader(`
+generated:
+  model: ""
+  cards:
+    - title: "Sans modèle"
+      prompt: "Dis bonjour."
+`))
+	if err != nil {
+		t.Fatalf("ReadConfig() failed: %v", err)
+	}
+	if _, err := config.GetGeneratedOptions(); err == nil {
+		t.Errorf("GetGeneratedOptions() succeeded without a model")
+	}
+}
+
+// messages returns the roles and contents of the messages of the request.
+func (r completionRequest) messages() [][2]string {
+	var messages [][2]string
+	list, _ := r.Body["messages"].([]any)
+	for _, message := range list {
+		m, _ := message.(map[string]any)
+		role, _ := m["role"].(string)
+		content, _ := m["content"].(string)
+		messages = append(messages, [2]string{role, content})
+	}
+	return messages
+}
+
+func TestGeneratedCardsHTML(t *testing.T) {
+	server := newCompletionServer(t, `<script>alert("boo")</script><b>Bonjour!</b>`)
+
+	cards := loadGeneratedCards(t, fmt.Sprintf(`
+generated:
+  base_url: "%s/v1"
+  cards:
+    - title: "Échappé"
+      prompt: "Dis bonjour."
+    - title: "HTML"
+      prompt: "Dis bonjour."
+      allow_html: true
+`, server.URL))
+
+	want := `&lt;script&gt;alert(&#34;boo&#34;)&lt;/script&gt;&lt;b&gt;Bonjour!&lt;/b&gt;`
+	if string(cards[0].Body) != want {
+		t.Errorf("got %s, want the text escaped: %s", cards[0].Body, want)
+	}
+	if want := `<script>alert("boo")</script><b>Bonjour!</b>`; string(cards[1].Body) != want {
+		t.Errorf("got %s, want the text as is: %s", cards[1].Body, want)
+	}
+}
+
+func TestGeneratedCardsTooLong(t *testing.T) {
+	server := newCompletionServer(t, "Il était une fois un zeppelin.")
+
+	cards := loadGeneratedCards(t, fmt.Sprintf(`
+generated:
+  base_url: "%s/v1"
+  cards:
+    - title: "Court"
+      prompt: "Raconte une histoire."
+      max_words: 3
+`, server.URL))
+
+	if got, want := string(cards[0].Body), "Il était une…"; got != want {
+		t.Errorf("got %s, want the text truncated: %s", got, want)
+	}
+	requests := server.Requests()
+	if len(requests) != maxGeneratedAttempts {
+		t.Fatalf("got %d requests, want %d", len(requests), maxGeneratedAttempts)
+	}
+	// Every retry follows the previous text with the feedback about it.
+	for attempt, request := range requests[1:] {
+		messages := request.messages()
+		want := 2 + 2*(attempt+1) // The date and prompt, then the previous texts and their feedback.
+		if len(messages) != want {
+			t.Fatalf("got %d messages in retry %d, want %d", len(messages), attempt+1, want)
+		}
+		text, feedback := messages[len(messages)-2], messages[len(messages)-1]
+		if text != [2]string{"assistant", "Il était une fois un zeppelin."} {
+			t.Errorf("got %v, want the previous text", text)
+		}
+		if feedback[0] != "user" || !strings.Contains(feedback[1], "It has 6 words, but must have at most 3.") {
+			t.Errorf("got %v, want the feedback about the length", feedback)
+		}
+	}
+}
+
+func TestGeneratedCardsBlocklist(t *testing.T) {
+	t.Run("rewritten", func(t *testing.T) {
+		server := newCompletionServer(t, "Oh ZUT, la guerre!", "Oh la la!")
+		cards := loadGeneratedCards(t, fmt.Sprintf(`
+generated:
+  base_url: "%s/v1"
+  blocklist: [zut]
+  cards:
+    - title: "Poli"
+      prompt: "Dis quelque chose."
+`, server.URL))
+
+		if got := string(cards[0].Body); got != "Oh la la!" {
+			t.Errorf("got %s, want the rewritten text", got)
+		}
+		requests := server.Requests()
+		if len(requests) != 2 {
+			t.Fatalf("got %d requests, want 2", len(requests))
+		}
+		messages := requests[1].messages()
+		if feedback := messages[len(messages)-1][1]; !strings.Contains(feedback, `don't use words like "zut"`) {
+			t.Errorf("got feedback %q, want it to mention the blocked word", feedback)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		server := newCompletionServer(t, "Oh ZUT, la guerre!")
+		cards := newTestGeneratedCards(t, fmt.Sprintf(`
+generated:
+  base_url: "%s/v1"
+  blocklist: [zut]
+  cards:
+    - title: "Poli"
+      prompt: "Dis quelque chose."
+`, server.URL))
+
+		if err := cards[0].Load(); err == nil || !strings.Contains(err.Error(), "blocked word") {
+			t.Errorf("got error %v, want the text rejected for its blocked word", err)
+		}
+		if got := len(server.Requests()); got != maxGeneratedAttempts {
+			t.Errorf("got %d requests, want %d", got, maxGeneratedAttempts)
+		}
+	})
+}
+
+func TestBlockedWord(t *testing.T) {
+	blocklist := []string{"zut", "ta gueule", "t'es nul", "sous-marin"}
+	for _, test := range []struct {
+		text string
+		want string
+	}{
+		{"Oh Zut alors!", "zut"},
+		{"Zutalors", ""},
+		{"Ta\nGUEULE, le chat.", "ta gueule"},
+		{"Ta belle gueule.", ""},
+		{"T’es nul!", "t'es nul"},
+		{"Le sous-marin jaune.", "sous-marin"},
+		{"Le sous marin jaune.", ""},
+		{"Bonjour.", ""},
+	} {
+		got, ok := blockedWord(test.text, blocklist)
+		if got != test.want || ok != (test.want != "") {
+			t.Errorf("blockedWord(%q) = %q, %v, want %q", test.text, got, ok, test.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		maxWords int
+		maxChars int
+		want     string
+	}{
+		{"Élève à l'école", 0, 0, "Élève à l'école"},
+		{"Élève à l'école", 0, 15, "Élève à l'école"},
+		{"Élève à l'école", 0, 7, "Élève…"},
+		{"Élève à l'école", 0, 8, "Élève à…"},
+		{"🦕🦖🐉🐲", 0, 3, "🦕🦖…"},
+		{"Élève à l'école", 2, 0, "Élève à…"},
+		{"Élève à l'école", 2, 5, "Élèv…"},
+	} {
+		if got := truncate(test.text, test.maxWords, test.maxChars); got != test.want {
+			t.Errorf("truncate(%q, %d, %d) = %q, want %q", test.text, test.maxWords, test.maxChars, got, test.want)
+		}
+	}
+}
+
+func TestPregenerateCards(t *testing.T) {
+	env := WhenEnv{Timezone: time.UTC}
+	today := midnight(time.Now().In(time.UTC))
+	history := filepath.Join(t.TempDir(), "generated.json")
+	config := func(url, prompt string) string {
+		return fmt.Sprintf(`
+generated:
+  base_url: "%s/v1"
+  history: { file: %q }
+  cards:
+    - title: "Histoire"
+      prompt: %q
+      when: { weekdays: [%s] }
+`, url, history, prompt, strings.ToLower(today.Weekday().String()))
+	}
+
+	// The card is only displayed on today's weekday, so only today's text is generated this week.
+	ahead := newCompletionServer(t, "Le texte d'avance.")
+	options := readGeneratedOptions(t, config(ahead.URL, "Raconte une histoire."))
+	if err := PregenerateCards(options, 7, env); err != nil {
+		t.Fatalf("PregenerateCards() failed: %v", err)
+	}
+	if got := len(ahead.Requests()); got != 1 {
+		t.Fatalf("got %d requests, want 1 for today", got)
+	}
+
+	t.Run("kept", func(t *testing.T) {
+		live := newCompletionServer(t, "Le texte en direct.")
+		cards := NewGeneratedCards(readGeneratedOptions(t, config(live.URL, "Raconte une histoire.")), env)
+		if err := cards[0].Load(); err != nil {
+			t.Fatalf("failed to load card: %v", err)
+		}
+		if got := string(cards[0].Body); got != "Le texte d&#39;avance." {
+			t.Errorf("got %s, want the text generated ahead of time", got)
+		}
+		if got := len(live.Requests()); got != 0 {
+			t.Errorf("got %d requests, want none", got)
+		}
+	})
+
+	t.Run("prompt changed", func(t *testing.T) {
+		live := newCompletionServer(t, "Le texte en direct.")
+		cards := NewGeneratedCards(readGeneratedOptions(t, config(live.URL, "Raconte une autre histoire.")), env)
+		if err := cards[0].Load(); err != nil {
+			t.Fatalf("failed to load card: %v", err)
+		}
+		if got := string(cards[0].Body); got != "Le texte en direct." {
+			t.Errorf("got %s, want the text generated from the new prompt", got)
+		}
+		if got := len(live.Requests()); got != 1 {
+			t.Errorf("got %d requests, want 1", got)
+		}
+	})
+}
+
+func TestPregenerateCardsNotToday(t *testing.T) {
+	server := newCompletionServer(t, "Bonjour!", "Salut!")
+	tomorrow := midnight(time.Now().In(time.UTC)).AddDate(0, 0, 1)
+
+	// Only tomorrow's prompt uses the weather, which is only known for today.
+	options := readGeneratedOptions(t, fmt.Sprintf(`
+generated:
+  base_url: "%s/v1"
+  history: { file: %q }
+  cards:
+    - title: "Météo"
+      prompt: '{{if eq (.Date.Format "2006-01-02") "%s"}}{{.Weather.MaxTemperature}}{{end}}Dis bonjour.'
+`, server.URL, filepath.Join(t.TempDir(), "generated.json"), tomorrow.Format("2006-01-02")))
+	if err := PregenerateCards(options, 3, WhenEnv{Timezone: time.UTC}); err != nil {
+		t.Fatalf("PregenerateCards() failed: %v", err)
+	}
+	if got := len(server.Requests()); got != 2 {
+		t.Errorf("got %d requests, want 2 for today and the day after tomorrow", got)
+	}
+}
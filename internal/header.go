@@ -10,12 +10,15 @@ import (
 // Header holds information for the header section of the screen.
 // The loader func should be used to populate the data.
 type Header struct {
-	Title template.HTML
+	Title   template.HTML
+	Holiday template.HTML
 
 	ConditionSVG   template.HTML
 	MaxTemperature int
 	MinTemperature int
 
+	Sensors []string // The readings of the local sensors, e.g. Dehors 3°.
+
 	loader func(*Header) error
 }
 
@@ -53,32 +56,47 @@ var replacer = strings.NewReplacer(
 	"December", "décembre",
 )
 
-// NewHeader creates a new Header using the given WeatherInfo.
-func NewHeader(weather WeatherInfo) Header {
-	return makeHeaderCard(weather, func() time.Time {
-		return time.Now()
+// NewHeader creates a new Header using the given WeatherInfo, HolidayInfo, and SensorsInfo, with today's date in the
+// given timezone.
+func NewHeader(weather WeatherInfo, holidays HolidayInfo, sensors SensorsInfo, timezone *time.Location) Header {
+	return makeHeaderCard(weather, holidays, sensors, func() time.Time {
+		return time.Now().In(timezone)
 	})
 }
 
-// NewFakeHeader creates a new Header using the given WeatherInfo with a random date for testing purposes.
-func NewFakeHeader(weather WeatherInfo) Header {
-	return makeHeaderCard(weather, func() time.Time {
-		return time.Now().Add(24 * time.Hour * time.Duration(rand.Intn(364)))
+// NewFakeHeader creates a new Header using the given WeatherInfo, HolidayInfo, and SensorsInfo with a random date for
+// testing purposes.
+func NewFakeHeader(weather WeatherInfo, holidays HolidayInfo, sensors SensorsInfo, timezone *time.Location) Header {
+	return makeHeaderCard(weather, holidays, sensors, func() time.Time {
+		return time.Now().In(timezone).Add(24 * time.Hour * time.Duration(rand.Intn(364)))
 	})
 }
 
-func makeHeaderCard(weather WeatherInfo, getTime func() time.Time) Header {
+func makeHeaderCard(weather WeatherInfo, holidays HolidayInfo, sensors SensorsInfo, getTime func() time.Time) Header {
 	return Header{
 		loader: func(h *Header) error {
 			now := getTime()
 			if err := weather.Load(); err != nil {
 				return err
 			}
+			if err := holidays.Load(); err != nil {
+				return err
+			}
+			if err := sensors.Load(); err != nil {
+				return err
+			}
 
 			h.Title = template.HTML(replacer.Replace(now.Format("Monday 2 January")))
+			h.Holiday = template.HTML(holidays.Name)
 			h.ConditionSVG = template.HTML(weather.Condition)
 			h.MaxTemperature = weather.MaxTemperature
 			h.MinTemperature = weather.MinTemperature
+			h.Sensors = nil
+			for _, reading := range sensors.Readings {
+				if reading.AirQuality == "" {
+					h.Sensors = append(h.Sensors, reading.String())
+				}
+			}
 			return nil
 		},
 	}
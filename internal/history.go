@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// HistoryOptions holds options for keeping the texts generated for the cards.
+type HistoryOptions struct {
+	File          string  // Empty to keep nothing.
+	Days          int     // How long the texts are kept, and not to be repeated.
+	MaxSimilarity float64 // The similarity with a recent text from which a text is generated again.
+}
+
+// generatedStore keeps the texts generated for every card in a JSON file, keyed by card title and then by date, along
+// with the prompts they were generated from. A kept text is only used while its prompt is the same, so prompts using
+// data changing during the day, like the weather, are generated again when it changes.
+// It's safe for concurrent use by the cards loading in parallel.
+type generatedStore struct {
+	options HistoryOptions
+	mu      sync.Mutex
+}
+
+// generatedText is a text kept in the store.
+type generatedText struct {
+	Prompt string `json:"prompt"`
+	Text   string `json:"text"`
+}
+
+// newGeneratedStore returns a store using the given options, or nil if there's no file to keep the texts in.
+func newGeneratedStore(options HistoryOptions) *generatedStore {
+	if options.File == "" {
+		return nil
+	}
+	return &generatedStore{options: options}
+}
+
+// Get returns the text generated for the card on the given day from the given prompt, or false if there's none.
+func (s *generatedStore) Get(title string, day time.Time, prompt string) (string, bool, error) {
+	if s == nil {
+		return "", false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	texts, err := s.read()
+	if err != nil {
+		return "", false, err
+	}
+	text, ok := texts[title][day.Format("2006-01-02")]
+	if !ok || text.Prompt != prompt {
+		return "", false, nil
+	}
+	return text.Text, true, nil
+}
+
+// Recent returns the texts generated for the card in the days before the given one, oldest first.
+func (s *generatedStore) Recent(title string, day time.Time) ([]string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	texts, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	from := midnight(day).AddDate(0, 0, -s.options.Days).Format("2006-01-02")
+	to := midnight(day).Format("2006-01-02")
+
+	var recent []string
+	for _, date := range slices.Sorted(maps.Keys(texts[title])) {
+		if date >= from && date < to {
+			recent = append(recent, texts[title][date].Text)
+		}
+	}
+	return recent, nil
+}
+
+// Put keeps the text generated for the card on the given day from the given prompt, replacing any previous one, and
+// drops the texts older than the retention window.
+func (s *generatedStore) Put(title string, day time.Time, prompt, text string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	texts, err := s.read()
+	if err != nil {
+		return err
+	}
+	if texts[title] == nil {
+		texts[title] = map[string]generatedText{}
+	}
+	texts[title][day.Format("2006-01-02")] = generatedText{Prompt: prompt, Text: text}
+
+	oldest := midnight(time.Now().In(day.Location())).AddDate(0, 0, -s.options.Days).Format("2006-01-02")
+	for _, dates := range texts {
+		maps.DeleteFunc(dates, func(date string, _ generatedText) bool {
+			return date < oldest
+		})
+	}
+
+	data, err := json.MarshalIndent(texts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.options.File, data, 0644); err != nil {
+		return fmt.Errorf("failed to write generated history: %w", err)
+	}
+	return nil
+}
+
+// read returns the texts kept in the file, which doesn't exist until the first text is generated.
+func (s *generatedStore) read() (map[string]map[string]generatedText, error) {
+	texts := map[string]map[string]generatedText{}
+	data, err := os.ReadFile(s.options.File)
+	if os.IsNotExist(err) {
+		return texts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &texts); err != nil {
+		return nil, fmt.Errorf("failed to parse generated history: %w", err)
+	}
+	return texts, nil
+}
+
+// similarity returns the share of the significant words of the shorter text found in the other, from 0 for none to 1
+// when all of them are. Short words are ignored, so the articles and prepositions don't count, but years do.
+func similarity(a, b string) float64 {
+	words := func(text string) map[string]bool {
+		set := map[string]bool{}
+		for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len([]rune(word)) >= 4 {
+				set[word] = true
+			}
+		}
+		return set
+	}
+
+	wordsA, wordsB := words(a), words(b)
+	common := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			common++
+		}
+	}
+	shorter := min(len(wordsA), len(wordsB))
+	if shorter == 0 {
+		return 0
+	}
+	return float64(common) / float64(shorter)
+}
+
+// Repeats returns whether the text is too similar to any of the recent ones.
+func (s *generatedStore) Repeats(text string, recent []string) bool {
+	if s == nil {
+		return false
+	}
+	return slices.ContainsFunc(recent, func(r string) bool {
+		return similarity(text, r) >= s.options.MaxSimilarity
+	})
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSimilarity(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want float64
+	}{
+		{"En 1969, l'homme a marché sur la Lune.", "En 1969, l'homme a marché sur la Lune.", 1},
+		// Only the words of 4 letters or more count, regardless of case.
+		{"En 1969, l'homme a marché sur la Lune.", "EN 1969, L'HOMME A MARCHÉ SUR LA LUNE!", 1},
+		{"En 1969, l'homme a marché sur la Lune.", "En 1492, Christophe Colomb a découvert l'Amérique.", 0},
+		// The share is of the shorter text: 1969 and homme out of 1969, homme, and zeppelin.
+		{"En 1969, l'homme a marché sur la Lune.", "En 1969, un homme a vu un zeppelin.", 2.0 / 3},
+		{"En 1969, l'homme a marché sur la Lune.", "Le 20 juillet 1969, l'homme a marché.", 0.75},
+		{"Un os.", "Un os.", 0},
+		{"", "En 1969, l'homme a marché sur la Lune.", 0},
+	} {
+		if got := similarity(test.a, test.b); got != test.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+		if got := similarity(test.b, test.a); got != test.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+func TestGeneratedStore(t *testing.T) {
+	store := newGeneratedStore(HistoryOptions{
+		File:          filepath.Join(t.TempDir(), "generated.json"),
+		Days:          3,
+		MaxSimilarity: 0.5,
+	})
+	today := midnight(time.Now())
+	day := func(offset int) time.Time {
+		return today.AddDate(0, 0, offset).Add(8 * time.Hour)
+	}
+
+	// From 5 days ago to tomorrow, for two cards.
+	for offset := -5; offset <= 1; offset++ {
+		for _, title := range []string{"Histoire", "Blague"} {
+			text := strings.Join([]string{title, day(offset).Format("2006-01-02")}, " ")
+			if err := store.Put(title, day(offset), "prompt", text); err != nil {
+				t.Fatalf("Put() failed: %v", err)
+			}
+		}
+	}
+
+	t.Run("recent", func(t *testing.T) {
+		recent, err := store.Recent("Histoire", day(0))
+		if err != nil {
+			t.Fatalf("Recent() failed: %v", err)
+		}
+		var want []string
+		for offset := -3; offset < 0; offset++ {
+			want = append(want, "Histoire "+day(offset).Format("2006-01-02"))
+		}
+		if !slices.Equal(recent, want) {
+			t.Errorf("got %v, want the 3 days before today, oldest first: %v", recent, want)
+		}
+	})
+
+	t.Run("pruned", func(t *testing.T) {
+		// Older than the 3 days before today.
+		for _, offset := range []int{-5, -4} {
+			if _, ok, _ := store.Get("Blague", day(offset), "prompt"); ok {
+				t.Errorf("got a text %d days ago, want it dropped", -offset)
+			}
+		}
+		for _, offset := range []int{-3, 0, 1} {
+			text, ok, err := store.Get("Blague", day(offset), "prompt")
+			if err != nil {
+				t.Fatalf("Get() failed: %v", err)
+			}
+			if want := "Blague " + day(offset).Format("2006-01-02"); !ok || text != want {
+				t.Errorf("got %q, %v, want %q", text, ok, want)
+			}
+		}
+	})
+
+	t.Run("another prompt", func(t *testing.T) {
+		if text, ok, _ := store.Get("Blague", day(0), "another prompt"); ok {
+			t.Errorf("got %q, want none for another prompt", text)
+		}
+	})
+
+	t.Run("repeats", func(t *testing.T) {
+		recent := []string{"En 1969, l'homme a marché sur la Lune."}
+		if !store.Repeats("Le 20 juillet 1969, l'homme a marché.", recent) {
+			t.Errorf("got a new text, want a repeat")
+		}
+		if store.Repeats("En 1492, Christophe Colomb a découvert l'Amérique.", recent) {
+			t.Errorf("got a repeat, want a new text")
+		}
+	})
+}
+
+func TestMaxSimilarityValidation(t *testing.T) {
+	for _, test := range []struct {
+		value   string
+		wantErr bool
+	}{
+		{"0", true},
+		{"-0.5", true},
+		{"0.01", false},
+		{"1", false},
+		{"1.5", true},
+	} {
+		_, err := ReadConfig(strings.NewReader("generated: { history: { max_similarity: " + test.value + " } }"))
+		if (err != nil) != test.wantErr {
+			t.Errorf("got error %v for max similarity %s, want an error: %v", err, test.value, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package internal
+
+import (
+	_ "embed"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+)
+
+//go:embed holidays.yaml
+var holidaysYAML []byte
+
+// HolidaysOptions holds options for finding out about statutory and school holidays.
+type HolidaysOptions struct {
+	Rules    []holidayRule // The statutory holidays of the configured region.
+	Custom   []CustomHolidayOptions
+	Timezone *time.Location // Today is the current day in this timezone.
+}
+
+// CustomHolidayOptions holds a holiday that isn't in the embedded rules, e.g. a pedagogical day or spring break.
+type CustomHolidayOptions struct {
+	Name       string
+	Dates      DateRangeOptions
+	SchoolOpen bool // Whether schools stay open.
+}
+
+func (c Config) GetHolidaysOptions() (HolidaysOptions, error) {
+	var options HolidaysOptions
+
+	timezone, err := c.GetTimezone()
+	if err != nil {
+		return options, err
+	}
+	options.Timezone = timezone
+
+	if c.Holidays.Region != "" {
+		var rules map[string][]holidayRule
+		if err := yaml.Unmarshal(holidaysYAML, &rules); err != nil {
+			return options, fmt.Errorf("failed to parse holiday rules: %w", err)
+		}
+
+		region := strings.ToUpper(c.Holidays.Region)
+		country, _, _ := strings.Cut(region, "-")
+		if _, ok := rules[country]; !ok {
+			return options, fmt.Errorf("no holiday rules for %s", country)
+		}
+		options.Rules = append(options.Rules, rules[country]...)
+		if region != country {
+			options.Rules = append(options.Rules, rules[region]...)
+		}
+	}
+
+	for _, h := range c.Holidays.Custom {
+		dates, err := DateRange{Start: h.Date, End: h.End}.ToDateRangeOptions()
+		if err != nil {
+			return options, fmt.Errorf("failed to parse holiday dates (%s): %w", h.Name, err)
+		}
+		options.Custom = append(options.Custom, CustomHolidayOptions{
+			Name:       h.Name,
+			Dates:      dates,
+			SchoolOpen: h.SchoolOpen,
+		})
+	}
+	return options, nil
+}
+
+// HolidayInfo holds holiday information for the current day.
+// The loader func should be used to populate the data.
+type HolidayInfo struct {
+	Name      string // The name of today's holiday, if any.
+	SchoolDay bool   // Whether kids go to school today.
+
+	loader func(*HolidayInfo) error
+}
+
+// Load populates the HolidayInfo by calling its loader function.
+func (h *HolidayInfo) Load() error {
+	if h.loader != nil {
+		return h.loader(h)
+	}
+	return nil
+}
+
+// NewHolidayInfo creates a new HolidayInfo using the given options.
+func NewHolidayInfo(options HolidaysOptions) HolidayInfo {
+	return makeHolidayInfo(func() (string, bool) {
+		return options.On(time.Now().In(options.Timezone))
+	})
+}
+
+// NewFakeHolidayInfo creates a new HolidayInfo with a random holiday for testing purposes. The holiday is drawn once
+// per render, so the header, the conditions, and the prompts all see the same one.
+func NewFakeHolidayInfo() HolidayInfo {
+	var (
+		mu     sync.Mutex
+		name   string
+		school bool
+		last   time.Time
+	)
+	return makeHolidayInfo(func() (string, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if time.Since(last) < time.Second {
+			return name, school
+		}
+
+		switch rand.Intn(4) {
+		case 0:
+			name, school = "Journée pédagogique", false
+		case 1:
+			name, school = "Fête nationale", false
+		default:
+			name, school = "", true
+		}
+		last = time.Now()
+		return name, school
+	})
+}
+
+func makeHolidayInfo(getHoliday func() (string, bool)) HolidayInfo {
+	return HolidayInfo{
+		loader: func(h *HolidayInfo) error {
+			h.Name, h.SchoolDay = getHoliday()
+			return nil
+		},
+	}
+}
+
+// On returns the name of the holiday on the given day, if any, and whether it's a school day.
+func (o HolidaysOptions) On(t time.Time) (string, bool) {
+	day := midnight(t)
+	weekend := day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+
+	for _, r := range o.Rules {
+		// Holidays observed on a Friday can fall on the last day of the previous year.
+		for _, year := range []int{day.Year(), day.Year() + 1} {
+			if date, ok := r.On(year, day.Location()); ok && date.Equal(day) {
+				return r.Name, false
+			}
+		}
+	}
+	for _, h := range o.Custom {
+		if h.Dates.Includes(day) {
+			return h.Name, !weekend && h.SchoolOpen
+		}
+	}
+	return "", !weekend
+}
+
+// holidayRule describes how to compute the date of a statutory holiday for a given year.
+// See holidays.yaml for the details.
+type holidayRule struct {
+	Name     string `yaml:"name"`
+	Date     string `yaml:"date"`
+	Easter   bool   `yaml:"easter"`
+	Month    int    `yaml:"month"`
+	Weekday  string `yaml:"weekday"`
+	Nth      int    `yaml:"nth"`
+	Before   int    `yaml:"before"`
+	Offset   int    `yaml:"offset"`
+	Observed bool   `yaml:"observed"`
+}
+
+// On returns the date of the holiday in the given year, or false if the rule is invalid.
+func (r holidayRule) On(year int, loc *time.Location) (time.Time, bool) {
+	var date time.Time
+
+	switch {
+	case r.Date != "":
+		t, err := time.Parse("01-02", r.Date)
+		if err != nil {
+			return date, false
+		}
+		date = time.Date(year, t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	case r.Easter:
+		date = easter(year, loc)
+	case r.Month != 0:
+		weekday, ok := weekdays[strings.ToLower(r.Weekday)]
+		if !ok {
+			return date, false
+		}
+		switch {
+		case r.Before != 0:
+			date = time.Date(year, time.Month(r.Month), r.Before-1, 0, 0, 0, 0, loc)
+			for date.Weekday() != weekday {
+				date = date.AddDate(0, 0, -1)
+			}
+		case r.Nth > 0:
+			date = time.Date(year, time.Month(r.Month), 1, 0, 0, 0, 0, loc)
+			for date.Weekday() != weekday {
+				date = date.AddDate(0, 0, 1)
+			}
+			date = date.AddDate(0, 0, 7*(r.Nth-1))
+		case r.Nth < 0:
+			date = time.Date(year, time.Month(r.Month)+1, 0, 0, 0, 0, 0, loc)
+			for date.Weekday() != weekday {
+				date = date.AddDate(0, 0, -1)
+			}
+		default:
+			return date, false
+		}
+	default:
+		return date, false
+	}
+
+	date = date.AddDate(0, 0, r.Offset)
+	if r.Observed {
+		switch date.Weekday() {
+		case time.Saturday:
+			date = date.AddDate(0, 0, -1)
+		case time.Sunday:
+			date = date.AddDate(0, 0, 1)
+		}
+	}
+	return date, true
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// easter returns the date of Easter Sunday in the given year, using the anonymous Gregorian algorithm.
+func easter(year int, loc *time.Location) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+}
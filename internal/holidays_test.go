@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEaster(t *testing.T) {
+	for _, want := range []string{
+		"2000-04-23", "2008-03-23", "2019-04-21", "2024-03-31", "2025-04-20", "2026-04-05", "2038-04-25",
+	} {
+		date, err := time.Parse("2006-01-02", want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := easter(date.Year(), time.UTC); !got.Equal(date) {
+			t.Errorf("got Easter on %s in %d, want %s", got.Format("2006-01-02"), date.Year(), want)
+		}
+	}
+}
+
+func TestHolidays(t *testing.T) {
+	montreal := loadTestLocation(t, "America/Montreal")
+	for _, test := range []struct {
+		region     string
+		date       string
+		want       string
+		wantSchool bool
+	}{
+		{"CA-QC", "2025-04-18", "Vendredi saint", false},
+		{"CA-QC", "2025-04-21", "Lundi de Pâques", false},
+		// The Monday before May 25, strictly, even when the 25th is a Monday.
+		{"CA-QC", "2025-05-19", "Journée nationale des patriotes", false},
+		{"CA-QC", "2026-05-18", "Journée nationale des patriotes", false},
+		{"CA-QC", "2026-05-25", "", true},
+		{"CA-ON", "2025-05-19", "Fête de la Reine", false},
+		{"CA-QC", "2025-09-01", "Fête du Travail", false},
+		{"CA-QC", "2025-10-13", "Action de grâce", false},
+		{"CA-QC", "2025-10-14", "", true},
+		{"CA-QC", "2025-10-18", "", false}, // A Saturday.
+		{"US-WA", "2025-05-26", "Memorial Day", false},
+		{"US-WA", "2025-11-27", "Action de grâce", false},
+		{"US-WA", "2025-11-28", "Journée du patrimoine amérindien", false},
+		// Observed on the Friday before, in the previous year for New Year's Day.
+		{"US-WA", "2021-12-31", "Jour de l'An", false},
+		{"US-WA", "2027-06-18", "Juneteenth", false},
+		{"US-WA", "2027-06-21", "", true},
+	} {
+		t.Run(test.region+" "+test.date, func(t *testing.T) {
+			config, err := ReadConfig(strings.NewReader("holidays: { region: " + test.region + " }"))
+			if err != nil {
+				t.Fatalf("ReadConfig() failed: %v", err)
+			}
+			options, err := config.GetHolidaysOptions()
+			if err != nil {
+				t.Fatalf("GetHolidaysOptions() failed: %v", err)
+			}
+			day, err := time.ParseInLocation("2006-01-02", test.date, montreal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			name, school := options.On(day.Add(10 * time.Hour))
+			if name != test.want || school != test.wantSchool {
+				t.Errorf("got %q, school day: %v, want %q, school day: %v", name, school, test.want, test.wantSchool)
+			}
+		})
+	}
+}
+
+func TestHolidayRuleInvalid(t *testing.T) {
+	for _, rule := range []holidayRule{
+		{Name: "Sans date"},
+		{Name: "Mauvaise date", Date: "13-01"},
+		{Name: "Mauvais jour", Month: 5, Weekday: "lundi", Nth: 1},
+		{Name: "Sans rang", Month: 5, Weekday: "monday"},
+	} {
+		if date, ok := rule.On(2025, time.UTC); ok {
+			t.Errorf("got %v for rule %+v, want it invalid", date, rule)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prongbang/callx"
+)
+
+// NormalsOptions holds options for comparing today's temperatures with the climate normals.
+type NormalsOptions struct {
+	Enabled   bool
+	Years     int    // The number of past years the normals are computed over.
+	CacheFile string // Where the normals are kept, so the archive is fetched once a year. Empty to fetch it every time.
+	MinDiff   int    // The minimum difference with the normal max to display a message about it.
+}
+
+// climateArchiveURL is where the daily temperatures of the past years are fetched from.
+var climateArchiveURL = "https://archive-api.open-meteo.com"
+
+// defaultNormalsCacheFile returns where the normals are kept when no cache file is configured, in the user cache
+// directory, or an empty string if there's none.
+func defaultNormalsCacheFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "kidscreen", "normals.json")
+}
+
+// climateNormal holds the normal and record temperatures for a day of the year.
+type climateNormal struct {
+	Max        float64 `json:"max"`         // The mean max temperature.
+	RecordHigh float64 `json:"record_high"` // The highest max temperature.
+	RecordLow  float64 `json:"record_low"`  // The lowest min temperature.
+}
+
+// climateNormals holds the normals of every day of the year, keyed by MM-DD, for a location and units.
+type climateNormals struct {
+	Location LatLng                   `json:"location"`
+	Units    Units                    `json:"units"`
+	Years    int                      `json:"years"`
+	Last     int                      `json:"last"` // The last year the normals are computed over.
+	Days     map[string]climateNormal `json:"days"`
+}
+
+// loadClimateNormal returns the climate normal for the day of t, from the cache file if any and it matches the
+// location, units, and years up to last year, or else from the Open-Meteo archive. Returns false when there's no
+// normal for that day.
+func loadClimateNormal(options NormalsOptions, location LatLng, units Units, t time.Time) (climateNormal, bool, error) {
+	var normals climateNormals
+
+	if options.CacheFile != "" {
+		if data, err := os.ReadFile(options.CacheFile); err == nil {
+			if err := json.Unmarshal(data, &normals); err != nil {
+				return climateNormal{}, false, fmt.Errorf("failed to parse climate normals cache: %w", err)
+			}
+		}
+	}
+	last := t.Year() - 1
+	if normals.Location != location || normals.Units != units || normals.Years != options.Years || normals.Last != last {
+		var err error
+		normals, err = fetchClimateNormals(location, units, options.Years, last)
+		if err != nil {
+			return climateNormal{}, false, err
+		}
+		if options.CacheFile != "" {
+			data, err := json.Marshal(normals)
+			if err != nil {
+				return climateNormal{}, false, err
+			}
+			if err := os.MkdirAll(filepath.Dir(options.CacheFile), 0755); err != nil {
+				return climateNormal{}, false, fmt.Errorf("failed to create climate normals cache directory: %w", err)
+			}
+			if err := os.WriteFile(options.CacheFile, data, 0644); err != nil {
+				return climateNormal{}, false, fmt.Errorf("failed to write climate normals cache: %w", err)
+			}
+		}
+	}
+
+	normal, ok := normals.Days[t.Format("01-02")]
+	return normal, ok, nil
+}
+
+// fetchClimateNormals computes the normals of every day of the year from the daily temperatures of the given number
+// of years up to the last one.
+func fetchClimateNormals(location LatLng, units Units, years, last int) (climateNormals, error) {
+	normals := climateNormals{
+		Location: location,
+		Units:    units,
+		Years:    years,
+		Last:     last,
+		Days:     map[string]climateNormal{},
+	}
+
+	client := callx.New(callx.Config{
+		BaseURL: climateArchiveURL,
+		Timeout: 60,
+	})
+
+	path := fmt.Sprintf(
+		"/v1/archive?latitude=%f&longitude=%f&start_date=%d-01-01&end_date=%d-12-31"+
+			"&daily=temperature_2m_max,temperature_2m_min&timezone=auto",
+		location.Lat, location.Lng, last-years+1, last,
+	)
+	if units == UnitsImperial {
+		path += "&temperature_unit=fahrenheit"
+	}
+
+	resp := client.Get(path)
+	if resp.Code != 200 {
+		return normals, fmt.Errorf("failed to get climate data: %s", string(resp.Data))
+	}
+
+	var response struct {
+		Daily struct {
+			Dates    []string   `json:"time"`
+			MaxTemps []*float64 `json:"temperature_2m_max"` // Null when missing.
+			MinTemps []*float64 `json:"temperature_2m_min"`
+		} `json:"daily"`
+	}
+	if err := json.Unmarshal(resp.Data, &response); err != nil {
+		return normals, err
+	}
+	if len(response.Daily.MaxTemps) != len(response.Daily.Dates) ||
+		len(response.Daily.MinTemps) != len(response.Daily.Dates) {
+		return normals, fmt.Errorf("unexpected climate data length")
+	}
+
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	for i, date := range response.Daily.Dates {
+		if len(date) != len("2006-01-02") || response.Daily.MaxTemps[i] == nil || response.Daily.MinTemps[i] == nil {
+			continue
+		}
+		day := date[5:]
+		high, low := *response.Daily.MaxTemps[i], *response.Daily.MinTemps[i]
+
+		normal, ok := normals.Days[day]
+		if !ok {
+			normal = climateNormal{RecordHigh: math.Inf(-1), RecordLow: math.Inf(1)}
+		}
+		normal.RecordHigh = max(normal.RecordHigh, high)
+		normal.RecordLow = min(normal.RecordLow, low)
+		normals.Days[day] = normal
+
+		sums[day] += high
+		counts[day]++
+	}
+	for day, normal := range normals.Days {
+		normal.Max = sums[day] / float64(counts[day])
+		normals.Days[day] = normal
+	}
+	return normals, nil
+}
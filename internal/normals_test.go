@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// archiveServer serves the archive fixture in place of the Open-Meteo archive, and records the requested queries.
+type archiveServer struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func newArchiveServer(t *testing.T) *archiveServer {
+	t.Helper()
+	fixture := readTestData(t, "openmeteo_archive.json")
+	s := &archiveServer{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.queries = append(s.queries, r.URL.RawQuery)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	t.Cleanup(server.Close)
+
+	original := climateArchiveURL
+	climateArchiveURL = server.URL
+	t.Cleanup(func() { climateArchiveURL = original })
+	return s
+}
+
+// Queries returns the queries received so far.
+func (s *archiveServer) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+// The fixture covers a few days of 2022 to 2024, with missing temperatures on 2023-01-02 and 2023-03-01.
+func TestFetchClimateNormals(t *testing.T) {
+	server := newArchiveServer(t)
+
+	normals, err := fetchClimateNormals(LatLng{Lat: 45.5, Lng: -73.6}, UnitsMetric, 3, 2024)
+	if err != nil {
+		t.Fatalf("fetchClimateNormals() failed: %v", err)
+	}
+	if queries := server.Queries(); len(queries) != 1 {
+		t.Fatalf("got %d requests, want 1", len(queries))
+	} else if want := "start_date=2022-01-01&end_date=2024-12-31"; !strings.Contains(queries[0], want) {
+		t.Errorf("got query %s, want it to contain %s", queries[0], want)
+	}
+	if normals.Years != 3 || normals.Last != 2024 {
+		t.Errorf("got %d years up to %d, want 3 up to 2024", normals.Years, normals.Last)
+	}
+
+	for _, test := range []struct {
+		day  string
+		want climateNormal
+	}{
+		{"01-01", climateNormal{Max: -2, RecordHigh: 1, RecordLow: -20}},
+		// The day with a missing max is skipped altogether, its min included.
+		{"01-02", climateNormal{Max: -5, RecordHigh: -3, RecordLow: -15}},
+		{"02-29", climateNormal{Max: 2, RecordHigh: 2, RecordLow: -4}},
+	} {
+		if got, ok := normals.Days[test.day]; !ok || got != test.want {
+			t.Errorf("got %+v for %s, want %+v", got, test.day, test.want)
+		}
+	}
+	if got, ok := normals.Days["03-01"]; ok {
+		t.Errorf("got %+v for 03-01, want none without temperatures", got)
+	}
+}
+
+func TestLoadClimateNormalCache(t *testing.T) {
+	server := newArchiveServer(t)
+	options := NormalsOptions{Enabled: true, Years: 3, CacheFile: filepath.Join(t.TempDir(), "kidscreen", "normals.json")}
+	location := LatLng{Lat: 45.5, Lng: -73.6}
+
+	for _, test := range []struct {
+		name     string
+		day      time.Time
+		units    Units
+		requests int
+	}{
+		{"fetched", time.Date(2025, time.January, 1, 8, 0, 0, 0, time.UTC), UnitsMetric, 1},
+		{"cached", time.Date(2025, time.January, 2, 8, 0, 0, 0, time.UTC), UnitsMetric, 1},
+		{"other units", time.Date(2025, time.January, 2, 8, 0, 0, 0, time.UTC), UnitsImperial, 2},
+		{"new year", time.Date(2026, time.January, 1, 8, 0, 0, 0, time.UTC), UnitsImperial, 3},
+		{"cached again", time.Date(2026, time.January, 2, 8, 0, 0, 0, time.UTC), UnitsImperial, 3},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			normal, ok, err := loadClimateNormal(options, location, test.units, test.day)
+			if err != nil {
+				t.Fatalf("loadClimateNormal() failed: %v", err)
+			}
+			if !ok || normal.RecordHigh == 0 {
+				t.Errorf("got %+v, %v, want the normal of %s", normal, ok, test.day.Format("01-02"))
+			}
+			if got := len(server.Queries()); got != test.requests {
+				t.Errorf("got %d requests, want %d", got, test.requests)
+			}
+		})
+	}
+}
@@ -15,14 +15,20 @@ type PictureOptions struct {
 	PageURL     string // The URL of the page to scrape for pictures.
 	ImagesXPath string // The XPath to select the image elements.
 	LabelXPath  string // The XPath to select the label element relative to the image element.
+	When        WhenOptions
 }
 
-func (c Config) GetPictureOptions() PictureOptions {
+func (c Config) GetPictureOptions() (PictureOptions, error) {
+	when, err := c.Picture.When.ToWhenOptions()
+	if err != nil {
+		return PictureOptions{}, fmt.Errorf("invalid conditions for picture: %w", err)
+	}
 	return PictureOptions{
 		PageURL:     c.Picture.PageURL,
 		ImagesXPath: c.Picture.ImageXPath,
 		LabelXPath:  c.Picture.LabelXPath,
-	}
+		When:        when,
+	}, nil
 }
 
 // NewPictureCard creates a new picture Card using the given options.
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PollenOptions holds options for the pollen Card, fetched along with the air quality.
+type PollenOptions struct {
+	Species  []string     // Among the keys of pollenSpecies, none to hide the card.
+	MinLevel int          // The index of the lowest level in pollenLevels to display a species.
+	Months   []time.Month // The pollen season, the card is hidden outside of it.
+}
+
+func (c Pollen) ToPollenOptions() (PollenOptions, error) {
+	options := PollenOptions{}
+
+	for _, species := range c.Species {
+		if _, ok := pollenSpecies[species]; !ok {
+			return options, fmt.Errorf("unknown pollen species: %s", species)
+		}
+		options.Species = append(options.Species, species)
+	}
+
+	options.MinLevel = slices.Index(pollenLevelNames, c.MinLevel)
+	if options.MinLevel < 0 {
+		return options, fmt.Errorf("unknown pollen level: %s", c.MinLevel)
+	}
+
+	for _, month := range c.Months {
+		if month < 1 || month > 12 {
+			return options, fmt.Errorf("unknown month: %d", month)
+		}
+		options.Months = append(options.Months, time.Month(month))
+	}
+	return options, nil
+}
+
+// pollenSpecies holds the French name of each species, and the concentrations (grains/m³) from which each level
+// after the first is reached. Trees release a lot more pollen than grasses and weeds for the same symptoms.
+var pollenSpecies = map[string]struct {
+	Name   string
+	Levels [3]float64
+}{
+	"alder":   {Name: "Aulne", Levels: [3]float64{10, 50, 500}},
+	"birch":   {Name: "Bouleau", Levels: [3]float64{10, 50, 500}},
+	"olive":   {Name: "Olivier", Levels: [3]float64{10, 50, 500}},
+	"grass":   {Name: "Graminées", Levels: [3]float64{5, 30, 200}},
+	"mugwort": {Name: "Armoise", Levels: [3]float64{5, 20, 100}},
+	"ragweed": {Name: "Ambroisie", Levels: [3]float64{5, 20, 100}},
+}
+
+// pollenLevelNames holds the levels as configured, pollenLevels as displayed.
+var pollenLevelNames = []string{"low", "moderate", "high", "very_high"}
+var pollenLevels = []string{"faible", "modéré", "élevé", "très élevé"}
+
+// pollenLevel returns the index of the level reached by the concentration of the species.
+func pollenLevel(species string, concentration float64) int {
+	level := 0
+	for i, threshold := range pollenSpecies[species].Levels {
+		if concentration >= threshold {
+			level = i + 1
+		}
+	}
+	return level
+}
+
+func makePollenCard(options PollenOptions, getAirQuality func() (airQualityData, error)) Card {
+	if len(options.Species) == 0 {
+		return Card{}
+	}
+
+	card := Card{
+		Title:    "Pollen",
+		Type:     CardTypeList,
+		Priority: 40,
+		loader: func(c *Card) error {
+			c.Items = []string{}
+			data, err := getAirQuality()
+			if err != nil {
+				return err
+			}
+
+			highest := 0
+			for _, species := range options.Species {
+				peak := slices.Max(append([]float64{0}, data.Pollen[species]...))
+				level := pollenLevel(species, peak)
+				if peak <= 0 || level < options.MinLevel {
+					continue
+				}
+				c.Items = append(c.Items, fmt.Sprintf("%s: %s", pollenSpecies[species].Name, pollenLevels[level]))
+				highest = max(highest, level)
+			}
+
+			// More urgent as the levels get worse.
+			c.Priority = 40 + 20*highest
+			return nil
+		},
+	}
+	if len(options.Months) > 0 {
+		card.ShowWhen(WhenOptions{Months: options.Months}, WhenEnv{})
+	}
+	return card
+}
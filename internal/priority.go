@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+)
+
+// The metrics of a Card that priority rules can reference.
+const (
+	MetricMaxValue             = "max_value"               // The max value of the chart within the relevant hours.
+	MetricItems                = "items"                   // The number of items in the list, e.g. events.
+	MetricHoursUntilFirstEvent = "hours_until_first_event" // The hours until the next timed event today.
+)
+
+// PriorityRuleOptions sets the priority of a Card when one of its metrics is within bounds.
+type PriorityRuleOptions struct {
+	Metric   string
+	Above    *float64 // Exclusive.
+	Below    *float64 // Exclusive.
+	Priority int
+}
+
+func (c PriorityRule) ToPriorityRuleOptions() (PriorityRuleOptions, error) {
+	metrics := []string{MetricMaxValue, MetricItems, MetricHoursUntilFirstEvent}
+	if !slices.Contains(metrics, c.Metric) {
+		return PriorityRuleOptions{}, fmt.Errorf("unknown metric: %s", c.Metric)
+	}
+	return PriorityRuleOptions(c), nil
+}
+
+// GetCardsPriorityOptions returns the priority rules configured for cards, keyed by title.
+func (c Config) GetCardsPriorityOptions() (map[string][]PriorityRuleOptions, error) {
+	options := map[string][]PriorityRuleOptions{}
+	for title, card := range c.Cards {
+		for _, rule := range card.Priority {
+			r, err := rule.ToPriorityRuleOptions()
+			if err != nil {
+				return options, fmt.Errorf("invalid priority rule for card (%s): %w", title, err)
+			}
+			options[title] = append(options[title], r)
+		}
+	}
+	return options, nil
+}
+
+// PrioritizeWith adds rules overriding the priority of the Card once it's loaded. The first matching rule wins.
+func (c *Card) PrioritizeWith(rules []PriorityRuleOptions) {
+	c.priorities = append(c.priorities, rules...)
+}
+
+// Metric returns the value of the given metric, or false if the Card doesn't have it.
+func (c Card) Metric(name string) (float64, bool) {
+	switch name {
+	case MetricMaxValue:
+		if c.Type == CardTypeChart && len(c.Chart.Data) > 0 {
+			return float64(c.Chart.RelevantMaxValue()), true
+		}
+	case MetricItems:
+		if c.Type == CardTypeList {
+			return float64(len(c.Items)), true
+		}
+	}
+	value, ok := c.metrics[name]
+	return value, ok
+}
+
+// prioritize applies the first matching priority rule to the Card.
+func (c *Card) prioritize() {
+	for _, rule := range c.priorities {
+		value, ok := c.Metric(rule.Metric)
+		if !ok {
+			continue
+		}
+		if rule.Above != nil && value <= *rule.Above {
+			continue
+		}
+		if rule.Below != nil && value >= *rule.Below {
+			continue
+		}
+		c.Priority = rule.Priority
+		return
+	}
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"cmp"
 	"context"
 	"embed"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"sync"
 
 	"github.com/chromedp/chromedp"
@@ -68,6 +70,11 @@ func assembleData(header Header, cards []Card) (renderData, error) {
 			data.Cards = append(data.Cards, &card)
 		}
 	}
+
+	// Sort higher priority cards first, now that loaders had a chance to adjust them.
+	slices.SortFunc(data.Cards, func(a, b *Card) int {
+		return -1 * cmp.Compare(a.Priority, b.Priority)
+	})
 	return data, nil
 }
 
@@ -1,25 +1,61 @@
 package internal
 
 import (
-	"cmp"
 	"os"
-	"slices"
 )
 
-func Run(config Config, dev, fake bool, img, addr string) error {
+// Run renders the screen, or only generates the texts of the generated cards for the given number of days ahead of
+// time if it's not zero.
+func Run(config Config, dev, fake bool, img, addr string, generate int) error {
 	var cards []Card
 
-	cards = append(cards, func() Card {
-		options := config.GetAirQualityOptions()
+	timezone, err := config.GetTimezone()
+	if err != nil {
+		return err
+	}
+
+	holidays, err := func() (HolidayInfo, error) {
+		if fake {
+			return NewFakeHolidayInfo(), nil
+		}
+		options, err := config.GetHolidaysOptions()
+		if err != nil {
+			return HolidayInfo{}, err
+		}
+		return NewHolidayInfo(options), nil
+	}()
+	if err != nil {
+		return err
+	}
+
+	sensors := func() SensorsInfo {
 		if fake {
-			return NewFakeAirQualityCard(options)
+			return NewFakeSensorsInfo()
 		}
-		return NewAirQualityCard(options)
-	}())
+		return NewSensorsInfo(config.GetSensorsOptions())
+	}()
 
-	err := func() error {
+	airQuality, err := func() ([]Card, error) {
+		options, err := config.GetAirQualityOptions()
+		if err != nil {
+			return nil, err
+		}
 		if fake {
-			cards = append(cards, NewFakeCalendarCards()...)
+			return NewFakeAirQualityCards(options), nil
+		}
+		return NewAirQualityCards(options, sensors), nil
+	}()
+	if err != nil {
+		return err
+	}
+	cards = append(cards, airQuality...)
+
+	var calendar CalendarInfo
+	err = func() error {
+		var calendarCards []Card
+		if fake {
+			calendarCards, calendar = NewFakeCalendarCardsAndInfo()
+			cards = append(cards, calendarCards...)
 			return nil
 		}
 
@@ -27,44 +63,172 @@ func Run(config Config, dev, fake bool, img, addr string) error {
 		if err != nil {
 			return err
 		}
-		cards = append(cards, NewCalendarCards(options)...)
+		calendarCards, calendar = NewCalendarCardsAndInfo(options)
+		cards = append(cards, calendarCards...)
 		return nil
 	}()
 	if err != nil {
 		return err
 	}
 
-	cards = append(cards, func() []Card {
-		if fake {
-			return NewFakeGeneratedCards()
+	var weather WeatherInfo
+	err = func() error {
+		options, err := config.GetWeatherOptions()
+		if err != nil {
+			return err
 		}
-		options := config.GetGeneratedOptions()
-		if len(options.Cards) > 0 {
-			return NewGeneratedCards(options)
+
+		var weatherCards []Card
+		if fake {
+			weatherCards, weather = NewFakeWeatherCardAndInfo(options)
+		} else {
+			weatherCards, weather = NewWeatherCardAndInfo(options)
 		}
+		cards = append(cards, weatherCards...)
 		return nil
-	}()...)
+	}()
+	if err != nil {
+		return err
+	}
 
-	var weather WeatherInfo
-	cards = append(cards, func() []Card {
-		var cards []Card
+	daylight, err := func() (Card, error) {
+		options, err := config.GetDaylightOptions()
+		if err != nil {
+			return Card{}, err
+		}
+		if fake {
+			return NewFakeDaylightCard(options), nil
+		}
+		return NewDaylightCard(options), nil
+	}()
+	if err != nil {
+		return err
+	}
+	cards = append(cards, daylight)
+
+	alerts, err := func() (Card, error) {
+		if fake {
+			return NewFakeAlertsCard(), nil
+		}
+		options, err := config.GetAlertsOptions()
+		if err != nil {
+			return Card{}, err
+		}
+		return NewAlertsCard(options), nil
+	}()
+	if err != nil {
+		return err
+	}
+	cards = append(cards, alerts)
+
+	env := WhenEnv{Holidays: holidays, Calendar: calendar, Weather: weather, Timezone: timezone}
+
+	var contexts []GeneratedContext
+	birthdays, err := func() (Card, error) {
+		options, err := config.GetBirthdaysOptions()
+		if err != nil {
+			return Card{}, err
+		}
+		var card Card
+		var context GeneratedContext
 		if fake {
-			cards, weather = NewFakeWeatherCardAndInfo(config.GetWeatherOptions())
-			return cards
+			card, context = NewFakeBirthdaysCardAndContext(options)
+		} else {
+			card, context = NewBirthdaysCardAndContext(options)
+		}
+		if context != nil {
+			contexts = append(contexts, context)
+		}
+		return card, nil
+	}()
+	if err != nil {
+		return err
+	}
+	cards = append(cards, birthdays)
+
+	if generate > 0 {
+		options, err := config.GetGeneratedOptions()
+		if err != nil {
+			return err
 		}
-		cards, weather = NewWeatherCardAndInfo(config.GetWeatherOptions())
-		return cards
-	}()...)
+		return PregenerateCards(options, generate, env, contexts...)
+	}
 
-	cards = append(cards, func() Card {
-		return NewPictureCard(config.GetPictureOptions())
-	}())
+	generated, err := func() ([]Card, error) {
+		if fake {
+			return NewFakeGeneratedCards(), nil
+		}
+		options, err := config.GetGeneratedOptions()
+		if err != nil {
+			return nil, err
+		}
+		if len(options.Cards) == 0 {
+			return nil, nil
+		}
+		cards := NewGeneratedCards(options, env, contexts...)
+		for i := range cards {
+			cards[i].ShowWhen(options.Cards[i].When, env)
+		}
+		return cards, nil
+	}()
+	if err != nil {
+		return err
+	}
+	cards = append(cards, generated...)
+
+	picture, err := func() (Card, error) {
+		options, err := config.GetPictureOptions()
+		if err != nil {
+			return Card{}, err
+		}
+		card := NewPictureCard(options)
+		card.ShowWhen(options.When, env)
+		return card, nil
+	}()
+	if err != nil {
+		return err
+	}
+	cards = append(cards, picture)
+
+	countdown, err := func() (Card, error) {
+		options, err := config.GetCountdownOptions()
+		if err != nil {
+			return Card{}, err
+		}
+		if fake {
+			return NewFakeCountdownCard(options), nil
+		}
+		return NewCountdownCard(options), nil
+	}()
+	if err != nil {
+		return err
+	}
+	cards = append(cards, countdown)
+
+	// Apply the conditions and priority rules configured by card title, on top of those configured with the cards
+	// themselves.
+	when, err := config.GetCardsWhenOptions()
+	if err != nil {
+		return err
+	}
+	priorities, err := config.GetCardsPriorityOptions()
+	if err != nil {
+		return err
+	}
+	for i := range cards {
+		if options, ok := when[string(cards[i].Title)]; ok {
+			cards[i].ShowWhen(options, env)
+		}
+		if rules, ok := priorities[string(cards[i].Title)]; ok {
+			cards[i].PrioritizeWith(rules)
+		}
+	}
 
 	header := func() Header {
 		if fake {
-			return NewFakeHeader(weather)
+			return NewFakeHeader(weather, holidays, sensors, timezone)
 		}
-		return NewHeader(weather)
+		return NewHeader(weather, holidays, sensors, timezone)
 	}()
 
 	if fake {
@@ -76,11 +240,6 @@ func Run(config Config, dev, fake bool, img, addr string) error {
 		}...)
 	}
 
-	// Sort higher priority cards first.
-	slices.SortFunc(cards, func(a, b Card) int {
-		return -1 * cmp.Compare(a.Priority, b.Priority)
-	})
-
 	if dev {
 		DevRender(header, cards, addr)
 	} else {
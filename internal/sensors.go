@@ -0,0 +1,199 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SensorOptions holds options for reading a local sensor exposing JSON over HTTP.
+type SensorOptions struct {
+	Name       string
+	URL        string
+	Path       string          // The dotted path to the value in the JSON, e.g. sensors.0.temperature.
+	Unit       string          // Appended to the value, e.g. °.
+	AirQuality AirQualityIndex // The index of the value if it overrides the current hour of the air quality chart.
+}
+
+func (c Config) GetSensorsOptions() []SensorOptions {
+	var options []SensorOptions
+	for _, sensor := range c.Sensors {
+		options = append(options, SensorOptions{
+			Name:       sensor.Name,
+			URL:        sensor.URL,
+			Path:       sensor.Path,
+			Unit:       sensor.Unit,
+			AirQuality: AirQualityIndex(sensor.AirQuality),
+		})
+	}
+	return options
+}
+
+// SensorsInfo holds the current readings of the local sensors.
+// The loader func should be used to populate the data.
+type SensorsInfo struct {
+	Readings []SensorReading
+
+	loader func(*SensorsInfo) error
+}
+
+type SensorReading struct {
+	Name       string
+	Value      float64
+	Unit       string
+	AirQuality AirQualityIndex // Empty unless it's an air quality reading.
+}
+
+// String formats the reading for display, e.g. Dehors 3°.
+func (r SensorReading) String() string {
+	return fmt.Sprintf("%s %.0f%s", r.Name, r.Value, r.Unit)
+}
+
+// Load populates the SensorsInfo by calling its loader function.
+func (s *SensorsInfo) Load() error {
+	if s.loader != nil {
+		return s.loader(s)
+	}
+	return nil
+}
+
+// AirQuality returns the first air quality reading in the given index, or false if there's none.
+func (s SensorsInfo) AirQuality(index AirQualityIndex) (float64, bool) {
+	for _, reading := range s.Readings {
+		if reading.AirQuality == index {
+			return reading.Value, true
+		}
+	}
+	return 0, false
+}
+
+// NewSensorsInfo creates a new SensorsInfo polling the given sensors. Sensors that can't be read are left out, so
+// an unplugged thermometer doesn't prevent the rest of the screen from being rendered.
+func NewSensorsInfo(options []SensorOptions) SensorsInfo {
+	var once sync.Once
+	var readings []SensorReading
+
+	return makeSensorsInfo(func() []SensorReading {
+		once.Do(func() {
+			for _, sensor := range options {
+				value, err := readSensor(sensor)
+				if err != nil {
+					log.Printf("failed to read sensor (%s): %v", sensor.Name, err)
+					continue
+				}
+				readings = append(readings, SensorReading{
+					Name:       sensor.Name,
+					Value:      value,
+					Unit:       sensor.Unit,
+					AirQuality: sensor.AirQuality,
+				})
+			}
+		})
+		return readings
+	})
+}
+
+// NewFakeSensorsInfo creates a new SensorsInfo with fake readings for testing purposes.
+func NewFakeSensorsInfo() SensorsInfo {
+	return makeSensorsInfo(func() []SensorReading {
+		return []SensorReading{
+			{Name: "Dehors", Value: float64(rand.Intn(40) - 15), Unit: "°"},
+			{Name: "Chambre", Value: float64(rand.Intn(6) + 17), Unit: "°"},
+		}
+	})
+}
+
+func makeSensorsInfo(getReadings func() []SensorReading) SensorsInfo {
+	return SensorsInfo{
+		loader: func(s *SensorsInfo) error {
+			s.Readings = getReadings()
+			return nil
+		},
+	}
+}
+
+func readSensor(options SensorOptions) (float64, error) {
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(options.URL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return 0, err
+	}
+	return lookupJSONPath(data, options.Path)
+}
+
+// lookupJSONPath returns the number at the dotted path in the decoded JSON, where array elements are selected by
+// their index, e.g. sensors.0.temperature. Dots within keys are escaped with a backslash, e.g. pm2\.5_aqi.
+func lookupJSONPath(data any, path string) (float64, error) {
+	for _, key := range splitJSONPath(path) {
+		switch node := data.(type) {
+		case map[string]any:
+			value, ok := node[key]
+			if !ok {
+				return 0, fmt.Errorf("missing key in JSON: %s", key)
+			}
+			data = value
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return 0, fmt.Errorf("invalid index in JSON: %s", key)
+			}
+			data = node[i]
+		default:
+			return 0, fmt.Errorf("no %s in JSON value: %v", key, node)
+		}
+	}
+
+	switch value := data.(type) {
+	case float64:
+		return value, nil
+	case string:
+		// Some sensors report numbers as strings.
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) {
+			return 0, fmt.Errorf("not a number in JSON: %q", value)
+		}
+		return number, nil
+	}
+	return 0, fmt.Errorf("not a number in JSON: %v", data)
+}
+
+// splitJSONPath splits the dotted path into its keys, unescaping the dots within them.
+func splitJSONPath(path string) []string {
+	var keys []string
+	var key strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			key.WriteByte('.')
+			i++
+		case path[i] == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteByte(path[i])
+		}
+	}
+	return append(keys, key.String())
+}
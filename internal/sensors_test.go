@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(`{
+		"current": {"temp_c": -3.5},
+		"sensors": [{"temperature": 19}, {"temperature": "20.5"}],
+		"pm2.5_aqi": 42,
+		"name": "salon"
+	}`), &data); err != nil {
+		t.Fatalf("failed to decode the JSON: %v", err)
+	}
+
+	for _, test := range []struct {
+		path    string
+		want    float64
+		wantErr bool
+	}{
+		{path: "current.temp_c", want: -3.5},
+		{path: "sensors.0.temperature", want: 19},
+		{path: "sensors.1.temperature", want: 20.5}, // A number as a string.
+		{path: `pm2\.5_aqi`, want: 42},
+		{path: "pm2.5_aqi", wantErr: true},
+		{path: "current.humidity", wantErr: true},
+		{path: "sensors.2.temperature", wantErr: true},
+		{path: "sensors.first.temperature", wantErr: true},
+		{path: "current.temp_c.value", wantErr: true},
+		{path: "name", wantErr: true},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			got, err := lookupJSONPath(data, test.path)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("lookupJSONPath() failed: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAirQualitySensorIndex(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		index   string
+		wantErr bool
+	}{
+		{"same index", "us_aqi", false},
+		{"another index", "aqhi", true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := ReadConfig(strings.NewReader(`
+weather:
+  airquality:
+    index: ` + test.index + `
+sensors:
+  - { name: "Air", url: "http://192.168.1.22/json", path: 'pm2\.5_aqi', air_quality: us_aqi }
+`))
+			if err != nil {
+				t.Fatalf("ReadConfig() failed: %v", err)
+			}
+			if got := config.GetSensorsOptions()[0].Path; got != `pm2\.5_aqi` {
+				t.Errorf("got path %s, want the escaped dot kept", got)
+			}
+			if _, err := config.GetAirQualityOptions(); (err != nil) != test.wantErr {
+				t.Errorf("GetAirQualityOptions() returned %v, want an error: %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -1,15 +1,16 @@
 package internal
 
 import (
-	"encoding/json"
+	"cmp"
+	"errors"
 	"fmt"
 	"html/template"
+	"log"
 	"math"
 	"math/rand"
 	"strings"
 	"sync"
-
-	"github.com/innotechdevops/openmeteo"
+	"time"
 )
 
 type LatLng struct {
@@ -17,24 +18,128 @@ type LatLng struct {
 	Lng float32
 }
 
+// Units is the system of units for the weather data and thresholds.
+type Units string
+
+const (
+	UnitsMetric   Units = "metric"
+	UnitsImperial Units = "imperial"
+)
+
+// Temperature returns the symbol for temperatures, e.g. °C.
+func (u Units) Temperature() string {
+	if u == UnitsImperial {
+		return "°F"
+	}
+	return "°C"
+}
+
+// Rainfall returns the symbol for rainfall amounts, e.g. mm.
+func (u Units) Rainfall() string {
+	if u == UnitsImperial {
+		return "po"
+	}
+	return "mm"
+}
+
+// Snowfall returns the symbol for snowfall amounts, e.g. cm.
+func (u Units) Snowfall() string {
+	if u == UnitsImperial {
+		return "po"
+	}
+	return "cm"
+}
+
+// WindSpeed returns the symbol for wind speeds, e.g. km/h.
+func (u Units) WindSpeed() string {
+	if u == UnitsImperial {
+		return "mi/h"
+	}
+	return "km/h"
+}
+
 type WeatherOptions struct {
-	Location             LatLng
-	MinDiffThreshold     int
-	MinRainfallThreshold int // mm
-	MinSnowfallThreshold int // cm
-	RelevantHours        HoursOptions
-	Chart                ChartOptions
+	Location              LatLng
+	Timezone              *time.Location // The days and hours of the forecast are in this timezone.
+	Units                 Units
+	Provider              WeatherProvider
+	MinDiffThreshold      int
+	MinRainfallThreshold  float64 // mm or in, converted from the configured mm.
+	MinSnowfallThreshold  float64 // cm or in, converted from the configured cm.
+	MinGustsThreshold     int     // km/h or mph
+	MinWindChillThreshold int     // The difference between the air and apparent temperatures.
+	MinUVIndexThreshold   float64
+	RelevantHours         HoursOptions
+	Chart                 ChartOptions
+	Temperature           TemperatureOptions
+	Clothing              []ClothingItemOptions
+	ForecastDays          int // The number of days after today in the forecast card, zero to hide it.
+	Normals               NormalsOptions
 }
 
-func (c Config) GetWeatherOptions() WeatherOptions {
-	return WeatherOptions{
-		Location:             LatLng(c.Weather.Location),
-		MinDiffThreshold:     c.Weather.MinDiffThreshold,
-		MinRainfallThreshold: c.Weather.MinRainfallThreshold,
-		MinSnowfallThreshold: c.Weather.MinSnowfallThreshold,
-		RelevantHours:        c.Weather.Precipitations.Hours.ToHoursOptions(),
-		Chart:                c.Weather.Precipitations.Chart.ToChartOptions(),
+// TemperatureOptions holds options for the hourly temperature chart.
+type TemperatureOptions struct {
+	Enabled       bool
+	RelevantHours HoursOptions
+	Annotations   map[int]string // Labels for the hours of the school departure and pickup.
+}
+
+func (c Config) GetWeatherOptions() (WeatherOptions, error) {
+	provider, err := newWeatherProvider(c.Weather.Providers)
+	if err != nil {
+		return WeatherOptions{}, err
+	}
+	timezone, err := c.GetTimezone()
+	if err != nil {
+		return WeatherOptions{}, err
+	}
+	// The rainfall and snowfall thresholds are configured in mm and cm, as their keys say, whatever the units.
+	rainfall, snowfall := c.Weather.MinRainfallThreshold, c.Weather.MinSnowfallThreshold
+	if c.Units == UnitsImperial {
+		rainfall, snowfall = rainfall/25.4, snowfall/2.54
 	}
+	return WeatherOptions{
+		Location:              LatLng(c.Weather.Location),
+		Timezone:              timezone,
+		Units:                 c.Units,
+		Provider:              provider,
+		MinDiffThreshold:      c.Weather.MinDiffThreshold,
+		MinRainfallThreshold:  rainfall,
+		MinSnowfallThreshold:  snowfall,
+		MinGustsThreshold:     c.Weather.MinGustsThreshold,
+		MinWindChillThreshold: c.Weather.MinWindChillThreshold,
+		MinUVIndexThreshold:   c.Weather.MinUVIndexThreshold,
+		RelevantHours:         c.Weather.Precipitations.Hours.ToHoursOptions(),
+		Chart:                 c.Weather.Precipitations.Chart.ToChartOptions(),
+		Temperature: TemperatureOptions{
+			Enabled:       c.Weather.Temperature.Enabled,
+			RelevantHours: c.Weather.Temperature.Hours.ToHoursOptions(),
+			Annotations: func() map[int]string {
+				annotations := map[int]string{}
+				if c.Weather.Temperature.Departure != 0 {
+					annotations[int(c.Weather.Temperature.Departure.Hours())] = "Départ"
+				}
+				if c.Weather.Temperature.Pickup != 0 {
+					annotations[int(c.Weather.Temperature.Pickup.Hours())] = "Retour"
+				}
+				return annotations
+			}(),
+		},
+		ForecastDays: c.Weather.Forecast.Days,
+		Normals: NormalsOptions{
+			Enabled:   c.Weather.Normals.Enabled,
+			Years:     c.Weather.Normals.Years,
+			CacheFile: cmp.Or(c.Weather.Normals.CacheFile, defaultNormalsCacheFile()),
+			MinDiff:   c.Weather.Normals.MinDiffThreshold,
+		},
+		Clothing: func() []ClothingItemOptions {
+			var items []ClothingItemOptions
+			for _, item := range c.Weather.Clothing {
+				items = append(items, item.ToClothingItemOptions())
+			}
+			return items
+		}(),
+	}, nil
 }
 
 // WeatherInfo holds weather information for the current day.
@@ -43,6 +148,8 @@ type WeatherInfo struct {
 	Condition      string
 	MaxTemperature int
 	MinTemperature int
+	Rain           bool // Whether the rainfall exceeds the min threshold.
+	Snow           bool // Whether the snowfall exceeds the min threshold.
 
 	loader func(*WeatherInfo) error
 }
@@ -57,15 +164,27 @@ func (w *WeatherInfo) Load() error {
 
 // NewWeatherCardAndInfo creates a new weather Card and WeatherInfo using the given latitude and longitude.
 func NewWeatherCardAndInfo(options WeatherOptions) ([]Card, WeatherInfo) {
-	var once sync.Once
+	var once, normalOnce sync.Once
 	var weather weatherData
-	var err error
+	var normal climateNormal
+	var found bool
+	var err, normalErr error
 
 	return makeWeatherCardAndInfo(options, func() (weatherData, error) {
 		once.Do(func() {
-			weather, err = fetchWeatherData(options.Location)
+			weather, err = options.Provider.Fetch(options.Location, options.Units, options.Timezone)
 		})
 		return weather, err
+	}, func() (climateNormal, bool, error) {
+		if !options.Normals.Enabled {
+			return climateNormal{}, false, nil
+		}
+		normalOnce.Do(func() {
+			normal, found, normalErr = loadClimateNormal(
+				options.Normals, options.Location, options.Units, time.Now().In(options.Timezone),
+			)
+		})
+		return normal, found, normalErr
 	})
 }
 
@@ -95,24 +214,77 @@ func NewFakeWeatherCardAndInfo(options WeatherOptions) ([]Card, WeatherInfo) {
 
 		fmt.Println("rain", rainfall, "snow", snowfall)
 
+		today := midnight(time.Now().In(options.Timezone))
+		times := hoursOfDay(today)
+
+		// Spread the precipitations over the likeliest hours.
+		probabilities := getBiasedSmoothRandomValues(len(times), 10, 100)
+		precipitations := make([]float64, len(times))
+		total := 0
+		for _, p := range probabilities {
+			total += p * p
+		}
+		for i, p := range probabilities {
+			precipitations[i] = (rainfall + snowfall/10) * float64(p*p) / float64(total)
+		}
+
+		// Warmest in the afternoon, with a wind chill of a few degrees, and the UV index peaking at noon.
+		temperatures := make([]int, len(times))
+		apparentTemperatures := make([]int, len(times))
+		gusts := getBiasedSmoothRandomValues(len(times), 0, 80)
+		uvIndex := make([]float64, len(times))
+		uvMax := float64(rand.Intn(11))
+		for i, t := range times {
+			hour := t.Hour()
+			shape := (1 - math.Cos(float64(hour-3)/24*2*math.Pi)) / 2
+			temperatures[i] = minToday + int(shape*float64(maxToday-minToday))
+			apparentTemperatures[i] = temperatures[i] - rand.Intn(5) - gusts[i]/10
+			uvIndex[i] = math.Round(max(0, uvMax*math.Cos(float64(hour-12)/12*math.Pi)))
+		}
+
+		var forecast []dailyForecast
+		for i := range maxForecastDays {
+			max := maxToday + 10 - rand.Intn(20)
+			forecast = append(forecast, dailyForecast{
+				Date:        today.AddDate(0, 0, i+1),
+				Condition:   condition,
+				Temperature: temperatureData{Max: max, Min: max - rand.Intn(15)},
+				WindSpeed:   float64(rand.Intn(60)),
+			})
+		}
+
 		return weatherData{
 			TemperatureToday: temperatureData{
 				Max: maxToday,
 				Min: minToday,
 			},
-			TemperatureYesterday: temperatureData{
+			TemperatureYesterday: &temperatureData{
 				Max: maxYesterday,
 				Min: minYesterday,
 			},
 			Condition:                        condition,
-			HourlyPrecipitationProbabilities: getBiasedSmoothRandomValues(24, 10, 100),
+			HourlyTimes:                      times,
+			HourlyPrecipitationProbabilities: probabilities,
+			HourlyTemperatures:               temperatures,
+			HourlyApparentTemperatures:       apparentTemperatures,
+			HourlyPrecipitations:             precipitations,
+			HourlyWindGusts:                  gusts,
+			HourlyUVIndex:                    uvIndex,
 			Rainfall:                         rainfall,
 			Snowfall:                         snowfall,
+			UVIndex:                          uvMax,
+			WindSpeed:                        float64(rand.Intn(60)),
+			Forecast:                         forecast,
 		}, nil
+	}, func() (climateNormal, bool, error) {
+		high := float64(rand.Intn(40) - 10)
+		return climateNormal{Max: high, RecordHigh: high + 10, RecordLow: high - 25}, true, nil
 	})
 }
 
-func makeWeatherCardAndInfo(options WeatherOptions, getWeather func() (weatherData, error)) ([]Card, WeatherInfo) {
+func makeWeatherCardAndInfo(
+	options WeatherOptions, getWeather func() (weatherData, error), getNormal func() (climateNormal, bool, error),
+) ([]Card, WeatherInfo) {
 	return []Card{
 			{
 				Title:    "Précipitations",
@@ -127,12 +299,68 @@ func makeWeatherCardAndInfo(options WeatherOptions, getWeather func() (weatherDa
 
 					c.Chart = Chart{
 						Data:    data.HourlyPrecipitationProbabilities,
+						Times:   data.HourlyTimes,
 						Hours:   options.RelevantHours,
 						Options: options.Chart,
 					}
 					return nil
 				},
 			},
+			{
+				Title:    "Température",
+				Type:     CardTypeChart,
+				Priority: 55,
+				loader: func(c *Card) error {
+					c.Chart = Chart{}
+					if !options.Temperature.Enabled {
+						return nil
+					}
+					data, err := getWeather()
+					if err != nil {
+						return err
+					}
+
+					c.Chart = Chart{
+						Type:        ChartTypeLine,
+						Data:        data.HourlyTemperatures,
+						Times:       data.HourlyTimes,
+						Secondary:   data.HourlyApparentTemperatures,
+						Annotations: options.Temperature.Annotations,
+						Hours:       options.Temperature.RelevantHours,
+					}
+					c.Footer = "Ressentie en pointillés"
+					return nil
+				},
+			},
+			makeClothingCard(options, getWeather),
+			{
+				Title:    "Prévisions",
+				Type:     CardTypeForecast,
+				Priority: 35,
+				loader: func(c *Card) error {
+					c.Forecast = nil
+					if options.ForecastDays == 0 {
+						return nil
+					}
+					data, err := getWeather()
+					if err != nil {
+						return err
+					}
+
+					for i, day := range data.Forecast {
+						if i >= options.ForecastDays {
+							break
+						}
+						c.Forecast = append(c.Forecast, ForecastDay{
+							Name:           replacer.Replace(day.Date.Format("Monday")),
+							ConditionSVG:   template.HTML(conditionIcon(day.Condition, false, day.WindSpeed, options.Units)),
+							MaxTemperature: day.Temperature.Max,
+							MinTemperature: day.Temperature.Min,
+						})
+					}
+					return nil
+				},
+			},
 			{
 				Title:    "Météo",
 				Type:     CardTypeText,
@@ -144,18 +372,78 @@ func makeWeatherCardAndInfo(options WeatherOptions, getWeather func() (weatherDa
 					}
 					sb := strings.Builder{}
 
-					if data.Rainfall > float64(options.MinRainfallThreshold) {
-						sb.WriteString(fmt.Sprintf("%3.1f mm de pluie<br>", data.Rainfall))
+					units := options.Units
+					rain := data.Rainfall > options.MinRainfallThreshold
+					snow := data.Snowfall > options.MinSnowfallThreshold
+					if rain {
+						sb.WriteString(fmt.Sprintf("%3.1f %s de pluie<br>", data.Rainfall, units.Rainfall()))
+					}
+					if snow {
+						sb.WriteString(fmt.Sprintf("%3.1f %s de neige<br>", data.Snowfall, units.Snowfall()))
+					}
+					if start, end, ok := precipitationWindow(data.HourlyPrecipitations, data.HourlyTimes); ok && (rain || snow) {
+						kind := "précipitations"
+						if !snow {
+							kind = "pluie"
+						} else if !rain {
+							kind = "neige"
+						}
+						sb.WriteString(fmt.Sprintf("%s surtout entre %dh et %dh<br>", kind, start, end))
+					}
+
+					hours := options.RelevantHours
+					if gusts, ok := relevantMax(data.HourlyWindGusts, data.HourlyTimes, hours); ok && options.MinGustsThreshold > 0 &&
+						gusts >= options.MinGustsThreshold {
+						sb.WriteString(fmt.Sprintf("Rafales jusqu'à %d %s<br>", gusts, units.WindSpeed()))
+					}
+					if options.MinWindChillThreshold > 0 {
+						// Find the hour where the apparent temperature is the furthest below the air temperature.
+						chill, apparent := 0, 0
+						for i, temperature := range data.HourlyTemperatures {
+							if !hours.Includes(hourAt(data.HourlyTimes, i)) {
+								continue
+							}
+							if diff := temperature - data.HourlyApparentTemperatures[i]; diff > chill {
+								chill, apparent = diff, data.HourlyApparentTemperatures[i]
+							}
+						}
+						if chill >= options.MinWindChillThreshold {
+							sb.WriteString(fmt.Sprintf("Ressenti de %d%s avec le vent<br>", apparent, units.Temperature()))
+						}
+					}
+					if hour, uv := peakHour(data.HourlyUVIndex, data.HourlyTimes, hours); options.MinUVIndexThreshold > 0 &&
+						uv >= options.MinUVIndexThreshold {
+						sb.WriteString(fmt.Sprintf("Indice UV de %.0f vers %dh<br>", uv, hour))
+					}
+
+					// The normals are a nice to have, so the rest of the card is displayed without them.
+					normal, ok, err := getNormal()
+					if err != nil {
+						log.Println("failed to load climate normals:", err)
 					}
-					if data.Snowfall > float64(options.MinSnowfallThreshold) {
-						sb.WriteString(fmt.Sprintf("%3.1f cm de neige<br>", data.Snowfall))
+					if err == nil && ok {
+						today := data.TemperatureToday
+						diff := today.Max - int(math.Round(normal.Max))
+						switch {
+						case float64(today.Max) > normal.RecordHigh:
+							sb.WriteString("Chaleur record pour la date!<br>")
+						case float64(today.Min) < normal.RecordLow:
+							sb.WriteString("Froid record pour la date!<br>")
+						case diff >= options.Normals.MinDiff:
+							sb.WriteString(fmt.Sprintf("%d%s plus chaud que la normale<br>", diff, units.Temperature()))
+						case diff <= -options.Normals.MinDiff:
+							sb.WriteString(fmt.Sprintf("%d%s plus froid que la normale<br>", -diff, units.Temperature()))
+						}
 					}
 
-					diff := data.TemperatureToday.Max - data.TemperatureYesterday.Max
-					if diff > options.MinDiffThreshold {
-						sb.WriteString(fmt.Sprintf("%d°C plus chaud qu'hier", diff))
-					} else if diff < -options.MinDiffThreshold {
-						sb.WriteString(fmt.Sprintf("%d°C plus froid qu'hier", -diff))
+					// Not every provider knows about yesterday.
+					if data.TemperatureYesterday != nil {
+						diff := data.TemperatureToday.Max - data.TemperatureYesterday.Max
+						if diff > options.MinDiffThreshold {
+							sb.WriteString(fmt.Sprintf("%d%s plus chaud qu'hier", diff, units.Temperature()))
+						} else if diff < -options.MinDiffThreshold {
+							sb.WriteString(fmt.Sprintf("%d%s plus froid qu'hier", -diff, units.Temperature()))
+						}
 					}
 
 					c.Body = template.HTML(sb.String())
@@ -170,21 +458,92 @@ func makeWeatherCardAndInfo(options WeatherOptions, getWeather func() (weatherDa
 					return err
 				}
 
-				w.Condition = conditionToIcon[data.Condition]
+				night := isNight(time.Now().In(options.Timezone), options.Location)
+				w.Condition = conditionIcon(data.Condition, night, data.WindSpeed, options.Units)
 				w.MaxTemperature = data.TemperatureToday.Max
 				w.MinTemperature = data.TemperatureToday.Min
+				w.Rain = data.Rainfall > options.MinRainfallThreshold
+				w.Snow = data.Snowfall > options.MinSnowfallThreshold
 				return nil
 			},
 		}
 }
 
+// weatherData holds the forecast for today, normalized across providers, in the requested units.
 type weatherData struct {
-	Condition                        string
+	Condition                        string // One of the weather condition names in wmoConditions.
 	TemperatureToday                 temperatureData
-	TemperatureYesterday             temperatureData
+	TemperatureYesterday             *temperatureData // Nil if the provider doesn't know about yesterday.
+	HourlyTimes                      []time.Time      // The start of every hour of today, 23 or 25 of them on DST changes.
 	HourlyPrecipitationProbabilities []int
+	HourlyTemperatures               []int
+	HourlyApparentTemperatures       []int     // Accounting for the wind chill and humidity.
+	HourlyPrecipitations             []float64 // The water equivalent of the rain and snow, in mm or in.
+	HourlyWindGusts                  []int     // In km/h or mph.
+	HourlyUVIndex                    []float64
 	Rainfall                         float64
 	Snowfall                         float64
+	UVIndex                          float64         // The max for today.
+	WindSpeed                        float64         // The max for today, in km/h or mph.
+	Forecast                         []dailyForecast // The following days, up to maxForecastDays.
+}
+
+// maxForecastDays is the number of days after today requested from the providers.
+const maxForecastDays = 5
+
+type dailyForecast struct {
+	Date        time.Time
+	Condition   string // One of the weather condition names in wmoConditions.
+	Temperature temperatureData
+	WindSpeed   float64 // The max, in km/h or mph.
+}
+
+// peakHour returns the local hour with the highest value within the relevant hours, and that value.
+func peakHour(hourly []float64, times []time.Time, hours HoursOptions) (int, float64) {
+	peak, highest := 0, 0.0
+	for i, value := range hourly {
+		hour := hourAt(times, i)
+		if !hours.Includes(hour) {
+			continue
+		}
+		if value > highest {
+			peak, highest = hour, value
+		}
+	}
+	return peak, highest
+}
+
+// precipitationWindow returns the shortest window of hours holding most of the day's precipitations, as the hours
+// it starts and ends at, in local time. It returns false when the precipitations are spread over too much of the day
+// to be worth mentioning.
+func precipitationWindow(hourly []float64, times []time.Time) (int, int, bool) {
+	const share = 0.75 // Of the day's total.
+	const maxHours = 8 // The longest window worth mentioning.
+
+	total := 0.0
+	for _, p := range hourly {
+		total += p
+	}
+	if total <= 0 {
+		return 0, 0, false
+	}
+
+	start, end := 0, len(hourly)
+	for i := range hourly {
+		sum := 0.0
+		for j := i; j < len(hourly) && j-i < end-start; j++ {
+			sum += hourly[j]
+			if sum >= share*total {
+				start, end = i, j+1
+				break
+			}
+		}
+	}
+	if end-start > maxHours {
+		return 0, 0, false
+	}
+	// The window ends at the end of its last hour.
+	return hourAt(times, start), hourAt(times, end-1) + 1, true
 }
 
 type temperatureData struct {
@@ -192,90 +551,162 @@ type temperatureData struct {
 	Min int
 }
 
-func fetchWeatherData(location LatLng) (weatherData, error) {
-	var result weatherData
-
-	param := openmeteo.Parameter{
-		Latitude:  openmeteo.Float32(location.Lat),
-		Longitude: openmeteo.Float32(location.Lng),
-		Timezone:  openmeteo.String("auto"),
-		Daily: &[]string{
-			openmeteo.DailyWeatherCode,
-			openmeteo.DailyTemperature2mMin,
-			openmeteo.DailyTemperature2mMax,
-			openmeteo.DailyShowersSum,
-			openmeteo.DailySnowfallSum,
-		},
-		Hourly: &[]string{
-			openmeteo.HourlyPrecipitationProbability,
-		},
-		ForecastDays: openmeteo.Int(1),
-		PastDays:     openmeteo.Int(1),
+// WeatherProvider fetches today's forecast for a location from a weather service, today being the current day in the
+// given timezone.
+type WeatherProvider interface {
+	Name() string
+	Fetch(location LatLng, units Units, timezone *time.Location) (weatherData, error)
+}
+
+// newWeatherProvider returns a WeatherProvider which tries the named providers in order until one succeeds.
+func newWeatherProvider(names []string) (WeatherProvider, error) {
+	var providers failoverWeatherProvider
+	for _, name := range names {
+		switch name {
+		case "open-meteo":
+			providers = append(providers, openMeteoProvider{})
+		case "met-norway":
+			providers = append(providers, metNorwayProvider{})
+		default:
+			return nil, fmt.Errorf("unknown weather provider: %s", name)
+		}
 	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no weather provider configured")
+	}
+	return providers, nil
+}
 
-	m := openmeteo.New()
-	resp, err := m.Execute(param)
-	if err != nil {
-		return result, err
+// failoverWeatherProvider tries each WeatherProvider in order, and returns the first successful forecast.
+type failoverWeatherProvider []WeatherProvider
+
+func (p failoverWeatherProvider) Name() string {
+	var names []string
+	for _, provider := range p {
+		names = append(names, provider.Name())
 	}
+	return strings.Join(names, ", ")
+}
 
-	var response struct {
-		Daily struct {
-			Condition []int     `json:"weathercode"`
-			MinTemps  []float64 `json:"temperature_2m_min"`
-			MaxTemps  []float64 `json:"temperature_2m_max"`
-			Rainfall  []float64 `json:"showers_sum"`
-			Snowfall  []float64 `json:"snowfall_sum"`
-		} `json:"daily"`
-		Hourly struct {
-			PrecipitationProbs []int `json:"precipitation_probability"`
-		} `json:"hourly"`
+func (p failoverWeatherProvider) Fetch(location LatLng, units Units, timezone *time.Location) (weatherData, error) {
+	var errs error
+	for _, provider := range p {
+		data, err := provider.Fetch(location, units, timezone)
+		if err == nil {
+			return data, nil
+		}
+		errs = errors.Join(errs, fmt.Errorf("failed to fetch weather from %s: %w", provider.Name(), err))
 	}
+	return weatherData{}, errs
+}
 
-	err = json.NewDecoder(strings.NewReader(resp)).Decode(&response)
-	if err != nil {
-		return result, err
+// wmoCodeToCondition returns the name of the weather condition for a WMO weather interpretation code, as used by
+// Open-Meteo, or "unknown". See the bottom of https://open-meteo.com/en/docs for the codes.
+func wmoCodeToCondition(code int) string {
+	if condition, ok := wmoConditions[code]; ok {
+		return condition
+	}
+	return "unknown"
+}
+
+var wmoConditions = map[int]string{
+	0:  "clear-sky",
+	1:  "mainly-clear",
+	2:  "partly-cloudy",
+	3:  "overcast",
+	45: "fog",
+	48: "depositing-rime-fog",
+	51: "drizzle-light",
+	53: "drizzle-moderate",
+	55: "drizzle-dense",
+	56: "freezing-drizzle-light",
+	57: "freezing-drizzle-dense",
+	61: "rain-slight",
+	63: "rain-moderate",
+	65: "rain-heavy",
+	66: "freezing-rain-light",
+	67: "freezing-rain-heavy",
+	71: "snow-fall-slight",
+	73: "snow-fall-moderate",
+	75: "snow-fall-heavy",
+	77: "snow-grains",
+	80: "rain-showers-slight",
+	81: "rain-showers-moderate",
+	82: "rain-showers-violent",
+	85: "snow-showers-slight",
+	86: "snow-showers-heavy",
+	95: "thunderstorm-slight-or-moderate",
+	96: "thunderstorm-slight-hail",
+	99: "thunderstorm-heavy-hail",
+}
+
+// windySpeed is the max wind speed (km/h) from which the wind is shown instead of the sun or the clouds.
+const windySpeed = 40.0
+
+// conditionIcon returns the SVG name in the HTML for a weather condition, with the variants for the night and for
+// windy days without precipitations. Unknown conditions get the cloudy icon rather than a broken one.
+func conditionIcon(condition string, night bool, wind float64, units Units) string {
+	icon, ok := conditionToIcon[condition]
+	if !ok {
+		icon = "cloudy"
 	}
 
-	result.Condition = openmeteo.WeatherCodeName(response.Daily.Condition[0])
-	result.TemperatureYesterday.Max = int(response.Daily.MaxTemps[0])
-	result.TemperatureYesterday.Min = int(response.Daily.MinTemps[0])
-	result.TemperatureToday.Max = int(response.Daily.MaxTemps[1])
-	result.TemperatureToday.Min = int(response.Daily.MinTemps[1])
-	result.HourlyPrecipitationProbabilities = response.Hourly.PrecipitationProbs[24:]
-	result.Rainfall = response.Daily.Rainfall[0]
-	result.Snowfall = response.Daily.Snowfall[0]
+	threshold := windySpeed
+	if units == UnitsImperial {
+		threshold = windySpeed / 1.609
+	}
+	switch icon {
+	case "sunny", "partly-sunny", "cloudy", "overcast":
+		if wind >= threshold {
+			return "windy"
+		}
+	}
+
+	if night {
+		switch icon {
+		case "sunny":
+			return "clear-night"
+		case "partly-sunny":
+			return "partly-cloudy-night"
+		}
+	}
+	return icon
+}
 
-	return result, nil
+// isNight returns whether t is before the sunrise or after the sunset at the location.
+func isNight(t time.Time, location LatLng) bool {
+	sunrise, sunset, ok := sunTimes(t, location)
+	return ok && (t.Before(sunrise) || t.After(sunset))
 }
 
-// Map of Open-Meteo weather condition names to the SVG names in the HTML.
+// Map of the weather condition names to the SVG names in the HTML, for the day.
 var conditionToIcon = map[string]string{
-	"clear-sky":                          "sunny",
-	"mainly-clear":                       "sunny",
-	"partly-cloudy":                      "cloudy",
-	"overcast":                           "overcast",
-	"fog":                                "foggy",
-	"depositing-rime-fog":                "foggy",
-	"drizzle-light":                      "rainy",
-	"drizzle-moderate":                   "rainy",
-	"drizzle-dense":                      "rainy",
-	"freezing-drizzle-light":             "rainy",
-	"freezing-drizzle-dense":             "rainy",
-	"rain-slight":                        "rainy",
-	"rain-moderate":                      "rainy",
-	"rain-heavy":                         "rainy",
-	"freezing-rain-light":                "rainy",
-	"freezing-rain-heavy":                "rainy",
-	"snow-fall-slight":                   "snowy",
-	"snow-fall-moderate":                 "snowy",
-	"snow-fall-heavy":                    "snowy",
-	"snow-grains":                        "snowy",
-	"rain-showers-slight":                "rainy",
-	"rain-showers-moderate":              "rainy",
-	"rain-showers-violent":               "rainy",
-	"snow-showers-slight":                "snowy",
-	"snow-showers-heavy":                 "snowy",
-	"thunderstorm-slight-or-moderate":    "stormy",
-	"thunderstorm-slight-and-heavy-hail": "stormy",
+	"clear-sky":                       "sunny",
+	"mainly-clear":                    "sunny",
+	"partly-cloudy":                   "partly-sunny",
+	"overcast":                        "overcast",
+	"fog":                             "foggy",
+	"depositing-rime-fog":             "foggy",
+	"drizzle-light":                   "rainy",
+	"drizzle-moderate":                "rainy",
+	"drizzle-dense":                   "rainy",
+	"freezing-drizzle-light":          "rainy",
+	"freezing-drizzle-dense":          "rainy",
+	"rain-slight":                     "rainy",
+	"rain-moderate":                   "rainy",
+	"rain-heavy":                      "rainy",
+	"freezing-rain-light":             "rainy",
+	"freezing-rain-heavy":             "rainy",
+	"snow-fall-slight":                "snowy",
+	"snow-fall-moderate":              "snowy",
+	"snow-fall-heavy":                 "snowy",
+	"snow-grains":                     "snowy",
+	"rain-showers-slight":             "rainy",
+	"rain-showers-moderate":           "rainy",
+	"rain-showers-violent":            "rainy",
+	"snow-showers-slight":             "snowy",
+	"snow-showers-heavy":              "snowy",
+	"thunderstorm-slight-or-moderate": "stormy",
+	"thunderstorm-slight-hail":        "hail",
+	"thunderstorm-heavy-hail":         "hail",
 }
@@ -0,0 +1,277 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// metNorwayProvider fetches the forecast from MET Norway, see https://api.met.no/weatherapi/locationforecast/2.0.
+// It doesn't know about yesterday, nor about snowfall, so precipitation below freezing is counted as snow. It only
+// supports metric units, so the data is converted locally for imperial units.
+type metNorwayProvider struct{}
+
+func (metNorwayProvider) Name() string {
+	return "met-norway"
+}
+
+func (metNorwayProvider) Fetch(location LatLng, units Units, timezone *time.Location) (weatherData, error) {
+	url := fmt.Sprintf(
+		"https://api.met.no/weatherapi/locationforecast/2.0/complete?lat=%.4f&lon=%.4f",
+		location.Lat, location.Lng,
+	)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return weatherData{}, err
+	}
+	// MET Norway requires an identifying user agent.
+	req.Header.Set("User-Agent", "kidscreen github.com/albertb/kidscreen")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return weatherData{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return weatherData{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return weatherData{}, fmt.Errorf("failed to get weather data: %s", string(body))
+	}
+	return parseMetNorwayWeather(body, time.Now().In(timezone), units)
+}
+
+// parseMetNorwayWeather parses a forecast response, keeping only the data for the day of now.
+func parseMetNorwayWeather(body []byte, now time.Time, units Units) (weatherData, error) {
+	var result weatherData
+
+	// Convert from the metric units of the response when needed.
+	temperature := func(celsius float64) int {
+		if units == UnitsImperial {
+			return int(math.Round(celsius*9/5 + 32))
+		}
+		return int(math.Round(celsius))
+	}
+	rainfall := func(mm float64) float64 {
+		if units == UnitsImperial {
+			return mm / 25.4
+		}
+		return mm
+	}
+	windSpeed := func(metersPerSecond float64) float64 {
+		if units == UnitsImperial {
+			return metersPerSecond * 2.237
+		}
+		return metersPerSecond * 3.6
+	}
+	// Roughly 1 cm of snow per mm of water.
+	snowfall := func(mm float64) float64 {
+		if units == UnitsImperial {
+			return mm / 2.54
+		}
+		return mm
+	}
+
+	type summary struct {
+		SymbolCode string `json:"symbol_code"`
+	}
+	var response struct {
+		Properties struct {
+			Timeseries []struct {
+				Time time.Time `json:"time"`
+				Data struct {
+					Instant struct {
+						Details struct {
+							AirTemperature float64 `json:"air_temperature"`
+							WindSpeed      float64 `json:"wind_speed"`         // m/s
+							WindGusts      float64 `json:"wind_speed_of_gust"` // m/s
+							UVIndex        float64 `json:"ultraviolet_index_clear_sky"`
+						} `json:"details"`
+					} `json:"instant"`
+					Next1Hours *struct {
+						Summary summary `json:"summary"`
+						Details struct {
+							PrecipitationAmount        float64 `json:"precipitation_amount"`
+							ProbabilityOfPrecipitation float64 `json:"probability_of_precipitation"`
+						} `json:"details"`
+					} `json:"next_1_hours"`
+					Next12Hours *struct {
+						Summary summary `json:"summary"`
+					} `json:"next_12_hours"`
+				} `json:"data"`
+			} `json:"timeseries"`
+		} `json:"properties"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return result, err
+	}
+
+	today := midnight(now)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	// The timeseries starts at the current hour, so earlier hours are left at zero, and temperatures are filled in
+	// with the first known one below.
+	result.HourlyTimes = hoursOfDay(now)
+	hours := len(result.HourlyTimes)
+	result.HourlyPrecipitationProbabilities = make([]int, hours)
+	result.HourlyPrecipitations = make([]float64, hours)
+	result.HourlyWindGusts = make([]int, hours)
+	result.HourlyUVIndex = make([]float64, hours)
+	result.HourlyTemperatures = make([]int, hours)
+	result.HourlyApparentTemperatures = make([]int, hours)
+	first := -1
+	result.TemperatureToday.Max = math.MinInt
+	result.TemperatureToday.Min = math.MaxInt
+
+	// The following days are summarized on their own, from the coarser timeseries further away.
+	result.Forecast = make([]dailyForecast, maxForecastDays)
+	for i := range result.Forecast {
+		result.Forecast[i] = dailyForecast{
+			Date:        tomorrow.AddDate(0, 0, i),
+			Temperature: temperatureData{Max: math.MinInt, Min: math.MaxInt},
+		}
+	}
+
+	for _, entry := range response.Properties.Timeseries {
+		t := entry.Time.In(now.Location())
+		if t.Before(today) {
+			continue
+		}
+		if !t.Before(tomorrow) {
+			i := daysBetween(tomorrow, midnight(t))
+			if i >= len(result.Forecast) {
+				continue
+			}
+			day := &result.Forecast[i]
+			celsius := entry.Data.Instant.Details.AirTemperature
+			day.Temperature.Max = max(day.Temperature.Max, temperature(celsius))
+			day.Temperature.Min = min(day.Temperature.Min, temperature(celsius))
+			day.WindSpeed = max(day.WindSpeed, windSpeed(entry.Data.Instant.Details.WindSpeed))
+			if entry.Data.Next12Hours != nil && (day.Condition == "" || t.Hour() <= 6) {
+				day.Condition = metNorwaySymbolToCondition(entry.Data.Next12Hours.Summary.SymbolCode)
+			}
+			continue
+		}
+
+		// The timeseries is hourly for the first days, so every entry of today starts one of its hours.
+		hour := slices.IndexFunc(result.HourlyTimes, t.Equal)
+		if hour < 0 {
+			continue
+		}
+
+		celsius := entry.Data.Instant.Details.AirTemperature
+		result.TemperatureToday.Max = max(result.TemperatureToday.Max, temperature(celsius))
+		result.TemperatureToday.Min = min(result.TemperatureToday.Min, temperature(celsius))
+
+		result.UVIndex = max(result.UVIndex, entry.Data.Instant.Details.UVIndex)
+		result.WindSpeed = max(result.WindSpeed, windSpeed(entry.Data.Instant.Details.WindSpeed))
+		result.HourlyWindGusts[hour] = int(math.Round(windSpeed(entry.Data.Instant.Details.WindGusts)))
+		result.HourlyUVIndex[hour] = entry.Data.Instant.Details.UVIndex
+
+		result.HourlyTemperatures[hour] = temperature(celsius)
+		result.HourlyApparentTemperatures[hour] = temperature(
+			windChill(celsius, entry.Data.Instant.Details.WindSpeed*3.6),
+		)
+		if first < 0 {
+			first = hour
+		}
+
+		// Use the 12 hours outlook from 6h, or from the first entry when it's already later.
+		if entry.Data.Next12Hours != nil && (result.Condition == "" || t.Hour() <= 6) {
+			result.Condition = metNorwaySymbolToCondition(entry.Data.Next12Hours.Summary.SymbolCode)
+		}
+
+		if next := entry.Data.Next1Hours; next != nil {
+			result.HourlyPrecipitationProbabilities[hour] = int(next.Details.ProbabilityOfPrecipitation)
+			result.HourlyPrecipitations[hour] = rainfall(next.Details.PrecipitationAmount)
+			if celsius > 0 {
+				result.Rainfall += rainfall(next.Details.PrecipitationAmount)
+			} else {
+				result.Snowfall += snowfall(next.Details.PrecipitationAmount)
+			}
+		}
+	}
+	if first < 0 {
+		return result, fmt.Errorf("no weather data for today")
+	}
+	// Drop the days beyond the end of the timeseries.
+	for i, day := range result.Forecast {
+		if day.Condition == "" {
+			result.Forecast = result.Forecast[:i]
+			break
+		}
+	}
+	for hour := 0; hour < first; hour++ {
+		result.HourlyTemperatures[hour] = result.HourlyTemperatures[first]
+		result.HourlyApparentTemperatures[hour] = result.HourlyApparentTemperatures[first]
+	}
+	return result, nil
+}
+
+// windChill returns the apparent temperature (°C) given the air temperature (°C) and wind speed (km/h), using the
+// North American wind chill index. It's only defined for cold and windy conditions, otherwise it's the air
+// temperature.
+func windChill(temperature, wind float64) float64 {
+	if temperature > 10 || wind < 4.8 {
+		return temperature
+	}
+	v := math.Pow(wind, 0.16)
+	return 13.12 + 0.6215*temperature - 11.37*v + 0.3965*temperature*v
+}
+
+// metNorwaySymbolToCondition maps a MET Norway symbol code to the closest Open-Meteo weather condition name.
+func metNorwaySymbolToCondition(symbol string) string {
+	// Ignore the variants for the time of day, e.g. clearsky_day or fair_polartwilight.
+	symbol, _, _ = strings.Cut(symbol, "_")
+
+	if strings.Contains(symbol, "thunder") {
+		return "thunderstorm-slight-or-moderate"
+	}
+	switch symbol {
+	case "clearsky":
+		return "clear-sky"
+	case "fair":
+		return "mainly-clear"
+	case "partlycloudy":
+		return "partly-cloudy"
+	case "cloudy":
+		return "overcast"
+	case "fog":
+		return "fog"
+	case "lightrain":
+		return "rain-slight"
+	case "rain":
+		return "rain-moderate"
+	case "heavyrain":
+		return "rain-heavy"
+	case "lightrainshowers":
+		return "rain-showers-slight"
+	case "rainshowers":
+		return "rain-showers-moderate"
+	case "heavyrainshowers":
+		return "rain-showers-violent"
+	case "lightsleet", "lightsleetshowers", "sleet", "sleetshowers":
+		return "freezing-rain-light"
+	case "heavysleet", "heavysleetshowers":
+		return "freezing-rain-heavy"
+	case "lightsnow":
+		return "snow-fall-slight"
+	case "snow":
+		return "snow-fall-moderate"
+	case "heavysnow":
+		return "snow-fall-heavy"
+	case "lightsnowshowers", "snowshowers":
+		return "snow-showers-slight"
+	case "heavysnowshowers":
+		return "snow-showers-heavy"
+	}
+	return "unknown"
+}
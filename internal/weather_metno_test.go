@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The fixture starts on 2025-11-02 at 6h in Montreal, where DST ends that day at 2h. It's hourly until 2025-11-04,
+// then every 6 hours until 2025-11-10. The temperature is -3° at midnight and goes up by 0.5° every hour of the day.
+func TestParseMetNorwayWeatherDSTEnd(t *testing.T) {
+	montreal := loadTestLocation(t, "America/Montreal")
+	now := time.Date(2025, time.November, 2, 6, 30, 0, 0, montreal)
+
+	data, err := parseMetNorwayWeather(readTestData(t, "metno_dst_end.json"), now, UnitsMetric)
+	if err != nil {
+		t.Fatalf("parseMetNorwayWeather() failed: %v", err)
+	}
+
+	if got := len(data.HourlyTimes); got != 25 {
+		t.Fatalf("got %d hours, want 25", got)
+	}
+	if got := data.HourlyTimes[2].Hour(); got != 1 {
+		t.Errorf("got %dh as the third hour, want 1h as it repeats", got)
+	}
+	// 6h is the 8th hour of the day, the earlier ones take its temperature.
+	for hour, want := range map[int]int{0: 0, 7: 0, 8: 1, 24: 9} {
+		if got := data.HourlyTemperatures[hour]; got != want {
+			t.Errorf("got %d° at hour %d, want %d°", got, hour, want)
+		}
+	}
+	if data.TemperatureToday != (temperatureData{Max: 9, Min: 0}) {
+		t.Errorf("got today's temperatures %+v, want max 9 and min 0", data.TemperatureToday)
+	}
+	if data.TemperatureYesterday != nil {
+		t.Errorf("got yesterday's temperatures %+v, want none", data.TemperatureYesterday)
+	}
+	if data.Condition != "partly-cloudy" {
+		t.Errorf("got condition %s, want partly-cloudy from the 6h outlook", data.Condition)
+	}
+
+	// The precipitation is counted as snow at or below freezing, at 6h only.
+	if math.Abs(data.Snowfall-0.4) > 1e-9 {
+		t.Errorf("got %vcm of snow, want 0.4cm", data.Snowfall)
+	}
+	if math.Abs(data.Rainfall-17*0.4) > 1e-9 {
+		t.Errorf("got %vmm of rain, want 6.8mm", data.Rainfall)
+	}
+	if data.HourlyPrecipitations[6] != 0 || data.HourlyPrecipitations[7] != 0.4 {
+		t.Errorf("got precipitations %v, want none before 6h", data.HourlyPrecipitations)
+	}
+
+	if got := len(data.Forecast); got != maxForecastDays {
+		t.Fatalf("got %d forecast days, want %d", got, maxForecastDays)
+	}
+	for i, day := range data.Forecast {
+		if want := time.Date(2025, time.November, 3+i, 0, 0, 0, 0, montreal); !day.Date.Equal(want) {
+			t.Errorf("forecast day %d is %v, want %v", i, day.Date, want)
+		}
+		if day.Condition == "" || day.Temperature.Max < day.Temperature.Min {
+			t.Errorf("forecast day %d is incomplete: %+v", i, day)
+		}
+	}
+}
+
+func TestParseMetNorwayWeatherImperial(t *testing.T) {
+	montreal := loadTestLocation(t, "America/Montreal")
+	now := time.Date(2025, time.November, 2, 6, 30, 0, 0, montreal)
+
+	data, err := parseMetNorwayWeather(readTestData(t, "metno_dst_end.json"), now, UnitsImperial)
+	if err != nil {
+		t.Fatalf("parseMetNorwayWeather() failed: %v", err)
+	}
+	if data.TemperatureToday != (temperatureData{Max: 47, Min: 32}) {
+		t.Errorf("got today's temperatures %+v, want max 47°F and min 32°F", data.TemperatureToday)
+	}
+	if math.Abs(data.Rainfall-17*0.4/25.4) > 1e-9 {
+		t.Errorf("got %vin of rain, want %vin", data.Rainfall, 17*0.4/25.4)
+	}
+	// The wind of 5 m/s.
+	if math.Abs(data.WindSpeed-5*2.237) > 1e-9 {
+		t.Errorf("got a wind of %vmph, want %vmph", data.WindSpeed, 5*2.237)
+	}
+}
+
+func TestParseMetNorwayWeatherNoDataForToday(t *testing.T) {
+	montreal := loadTestLocation(t, "America/Montreal")
+	now := time.Date(2025, time.December, 2, 6, 30, 0, 0, montreal)
+
+	if _, err := parseMetNorwayWeather(readTestData(t, "metno_dst_end.json"), now, UnitsMetric); err == nil {
+		t.Errorf("parseMetNorwayWeather() succeeded for a day without data")
+	}
+}
+
+// stubWeatherProvider returns the given data, or error if set.
+type stubWeatherProvider struct {
+	name string
+	data weatherData
+	err  error
+}
+
+func (p stubWeatherProvider) Name() string {
+	return p.name
+}
+
+func (p stubWeatherProvider) Fetch(LatLng, Units, *time.Location) (weatherData, error) {
+	return p.data, p.err
+}
+
+func TestFailoverWeatherProvider(t *testing.T) {
+	montreal := loadTestLocation(t, "America/Montreal")
+	now := time.Date(2025, time.November, 2, 6, 30, 0, 0, montreal)
+	met, err := parseMetNorwayWeather(readTestData(t, "metno_dst_end.json"), now, UnitsMetric)
+	if err != nil {
+		t.Fatalf("parseMetNorwayWeather() failed: %v", err)
+	}
+
+	openMeteoDown := stubWeatherProvider{name: "open-meteo", err: errors.New("503 Service Unavailable")}
+	metNorway := stubWeatherProvider{name: "met-norway", data: met}
+
+	t.Run("switches to MET Norway", func(t *testing.T) {
+		provider := failoverWeatherProvider{openMeteoDown, metNorway}
+		data, err := provider.Fetch(LatLng{}, UnitsMetric, montreal)
+		if err != nil {
+			t.Fatalf("Fetch() failed: %v", err)
+		}
+		if data.Condition != met.Condition || len(data.HourlyTimes) != len(met.HourlyTimes) {
+			t.Errorf("got %+v, want the MET Norway forecast", data)
+		}
+	})
+
+	t.Run("all failing", func(t *testing.T) {
+		metNorwayDown := stubWeatherProvider{name: "met-norway", err: errors.New("429 Too Many Requests")}
+		provider := failoverWeatherProvider{openMeteoDown, metNorwayDown}
+		_, err := provider.Fetch(LatLng{}, UnitsMetric, montreal)
+		if err == nil {
+			t.Fatalf("Fetch() succeeded with all the providers failing")
+		}
+		for _, want := range []string{"open-meteo: 503", "met-norway: 429"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("got error %q, want it to mention %q", err, want)
+			}
+		}
+	})
+
+	t.Run("configured names", func(t *testing.T) {
+		provider, err := newWeatherProvider([]string{"open-meteo", "met-norway"})
+		if err != nil {
+			t.Fatalf("newWeatherProvider() failed: %v", err)
+		}
+		if got := provider.Name(); got != "open-meteo, met-norway" {
+			t.Errorf("got %s, want open-meteo, met-norway", got)
+		}
+		if _, err := newWeatherProvider([]string{"accuweather"}); err == nil {
+			t.Errorf("newWeatherProvider() succeeded with an unknown provider")
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/innotechdevops/openmeteo"
+)
+
+// openMeteoProvider fetches the forecast from Open-Meteo, see https://open-meteo.com/en/docs.
+type openMeteoProvider struct{}
+
+func (openMeteoProvider) Name() string {
+	return "open-meteo"
+}
+
+func (openMeteoProvider) Fetch(location LatLng, units Units, timezone *time.Location) (weatherData, error) {
+	param := openmeteo.Parameter{
+		Latitude:  openmeteo.Float32(location.Lat),
+		Longitude: openmeteo.Float32(location.Lng),
+		Timezone:  openmeteo.String(openMeteoTimezone(timezone)),
+		// The hours are matched with the local ones from their timestamps, as some days don't have 24 of them.
+		TimeFormat: openmeteo.String("unixtime"),
+		Daily: &[]string{
+			openmeteo.DailyWeatherCode,
+			openmeteo.DailyTemperature2mMin,
+			openmeteo.DailyTemperature2mMax,
+			openmeteo.DailyRainSum,
+			openmeteo.DailySnowfallSum,
+			openmeteo.DailyUvIndexMax,
+			openmeteo.DailyWindSpeed10mMax,
+		},
+		Hourly: &[]string{
+			openmeteo.HourlyPrecipitationProbability,
+			openmeteo.HourlyTemperature2m,
+			openmeteo.HourlyApparentTemperature,
+			openmeteo.HourlyPrecipitation,
+			openmeteo.HourlyWindGusts10m,
+			"uv_index",
+		},
+		ForecastDays: openmeteo.Int(1 + maxForecastDays),
+		PastDays:     openmeteo.Int(1),
+	}
+	if units == UnitsImperial {
+		param.TemperatureUnit = openmeteo.String("fahrenheit")
+		param.PrecipitationUnit = openmeteo.String("inch")
+		param.WindSpeedUnit = openmeteo.String("mph")
+	}
+
+	m := openmeteo.New()
+	resp, err := m.Execute(param)
+	if err != nil {
+		return weatherData{}, err
+	}
+	return parseOpenMeteoWeather(resp, time.Now().In(timezone))
+}
+
+// openMeteoTimezone returns the timezone the daily data should be requested in. The system timezone, used when none
+// is configured, has no name Open-Meteo knows about, so the one of the location is used instead.
+func openMeteoTimezone(timezone *time.Location) string {
+	if timezone == time.Local {
+		return "auto"
+	}
+	return timezone.String()
+}
+
+// parseOpenMeteoWeather parses a forecast response for yesterday, today, and the following days if any, keeping the
+// hours of the day of now.
+func parseOpenMeteoWeather(resp string, now time.Time) (weatherData, error) {
+	var result weatherData
+
+	var response struct {
+		Daily struct {
+			Dates     []int64   `json:"time"` // The unix time of the start of every day.
+			Condition []int     `json:"weathercode"`
+			MinTemps  []float64 `json:"temperature_2m_min"`
+			MaxTemps  []float64 `json:"temperature_2m_max"`
+			Rainfall  []float64 `json:"rain_sum"` // Including the showers, unlike showers_sum.
+			Snowfall  []float64 `json:"snowfall_sum"`
+			UVIndex   []float64 `json:"uv_index_max"`
+			WindSpeed []float64 `json:"windspeed_10m_max"`
+		} `json:"daily"`
+		Hourly struct {
+			Times                []int64   `json:"time"`
+			PrecipitationProbs   []int     `json:"precipitation_probability"`
+			Temperatures         []float64 `json:"temperature_2m"`
+			ApparentTemperatures []float64 `json:"apparent_temperature"`
+			Precipitations       []float64 `json:"precipitation"`
+			WindGusts            []float64 `json:"windgusts_10m"`
+			UVIndex              []float64 `json:"uv_index"`
+		} `json:"hourly"`
+	}
+
+	err := json.NewDecoder(strings.NewReader(resp)).Decode(&response)
+	if err != nil {
+		return result, err
+	}
+
+	// Expect two days of data, yesterday and today, and as many hourly values as there are hours.
+	hourly := len(response.Hourly.Times)
+	if len(response.Daily.Condition) < 2 || len(response.Daily.MinTemps) < 2 || len(response.Daily.MaxTemps) < 2 ||
+		len(response.Daily.Rainfall) < 2 || len(response.Daily.Snowfall) < 2 || len(response.Daily.UVIndex) < 2 ||
+		len(response.Daily.WindSpeed) < 2 ||
+		len(response.Hourly.PrecipitationProbs) != hourly || len(response.Hourly.Temperatures) != hourly ||
+		len(response.Hourly.ApparentTemperatures) != hourly || len(response.Hourly.Precipitations) != hourly ||
+		len(response.Hourly.WindGusts) != hourly || len(response.Hourly.UVIndex) != hourly {
+		return result, fmt.Errorf("unexpected weather data length")
+	}
+
+	result.Condition = wmoCodeToCondition(response.Daily.Condition[1])
+	result.TemperatureYesterday = &temperatureData{
+		Max: int(response.Daily.MaxTemps[0]),
+		Min: int(response.Daily.MinTemps[0]),
+	}
+	result.TemperatureToday.Max = int(response.Daily.MaxTemps[1])
+	result.TemperatureToday.Min = int(response.Daily.MinTemps[1])
+
+	today := midnight(now)
+	tomorrow := today.AddDate(0, 0, 1)
+	for i, unix := range response.Hourly.Times {
+		t := time.Unix(unix, 0).In(now.Location())
+		if t.Before(today) || !t.Before(tomorrow) {
+			continue
+		}
+		result.HourlyTimes = append(result.HourlyTimes, t)
+		result.HourlyPrecipitationProbabilities = append(
+			result.HourlyPrecipitationProbabilities, response.Hourly.PrecipitationProbs[i],
+		)
+		result.HourlyPrecipitations = append(result.HourlyPrecipitations, response.Hourly.Precipitations[i])
+		result.HourlyUVIndex = append(result.HourlyUVIndex, response.Hourly.UVIndex[i])
+		result.HourlyTemperatures = append(result.HourlyTemperatures, int(math.Round(response.Hourly.Temperatures[i])))
+		result.HourlyApparentTemperatures = append(
+			result.HourlyApparentTemperatures, int(math.Round(response.Hourly.ApparentTemperatures[i])),
+		)
+		result.HourlyWindGusts = append(result.HourlyWindGusts, int(math.Round(response.Hourly.WindGusts[i])))
+	}
+	if len(result.HourlyTimes) == 0 {
+		return result, fmt.Errorf("no hourly weather data for today")
+	}
+	result.Rainfall = response.Daily.Rainfall[1]
+	result.Snowfall = response.Daily.Snowfall[1]
+	result.UVIndex = response.Daily.UVIndex[1]
+	result.WindSpeed = response.Daily.WindSpeed[1]
+
+	for i := 2; i < len(response.Daily.Dates) && i < len(response.Daily.Condition) &&
+		i < len(response.Daily.MaxTemps) && i < len(response.Daily.MinTemps) && i < len(response.Daily.WindSpeed); i++ {
+		result.Forecast = append(result.Forecast, dailyForecast{
+			Date:      midnight(time.Unix(response.Daily.Dates[i], 0).In(now.Location())),
+			Condition: wmoCodeToCondition(response.Daily.Condition[i]),
+			Temperature: temperatureData{
+				Max: int(response.Daily.MaxTemps[i]),
+				Min: int(response.Daily.MinTemps[i]),
+			},
+			WindSpeed: response.Daily.WindSpeed[i],
+		})
+	}
+
+	return result, nil
+}
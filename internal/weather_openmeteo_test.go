@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadTestLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load timezone %s: %v", name, err)
+	}
+	return location
+}
+
+func readTestData(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to read test data: %v", err)
+	}
+	return data
+}
+
+// The fixture covers 2025-11-01 to 2025-11-07 in Montreal, where DST ends on 2025-11-02 at 2h.
+func TestParseOpenMeteoWeatherDSTEnd(t *testing.T) {
+	montreal := loadTestLocation(t, "America/Montreal")
+	now := time.Date(2025, time.November, 2, 15, 0, 0, 0, montreal)
+
+	data, err := parseOpenMeteoWeather(string(readTestData(t, "openmeteo_dst_end.json")), now)
+	if err != nil {
+		t.Fatalf("parseOpenMeteoWeather() failed: %v", err)
+	}
+
+	if got := len(data.HourlyTimes); got != 25 {
+		t.Fatalf("got %d hours, want 25", got)
+	}
+	if want := time.Date(2025, time.November, 2, 0, 0, 0, 0, montreal); !data.HourlyTimes[0].Equal(want) {
+		t.Errorf("first hour is %v, want %v", data.HourlyTimes[0], want)
+	}
+	var hours []int
+	for _, hour := range data.HourlyTimes[:4] {
+		hours = append(hours, hour.Hour())
+	}
+	if want := []int{0, 1, 1, 2}; !slices.Equal(hours, want) {
+		t.Errorf("got hours %v, want %v as 1h repeats", hours, want)
+	}
+
+	for _, series := range []struct {
+		name   string
+		length int
+	}{
+		{"precipitation probabilities", len(data.HourlyPrecipitationProbabilities)},
+		{"temperatures", len(data.HourlyTemperatures)},
+		{"apparent temperatures", len(data.HourlyApparentTemperatures)},
+		{"precipitations", len(data.HourlyPrecipitations)},
+		{"wind gusts", len(data.HourlyWindGusts)},
+		{"UV index", len(data.HourlyUVIndex)},
+	} {
+		if series.length != 25 {
+			t.Errorf("got %d hourly %s, want 25", series.length, series.name)
+		}
+	}
+	// The fixture values go up by one every hour of the day, from -4.7 at midnight.
+	if got := data.HourlyTemperatures[0]; got != -5 {
+		t.Errorf("got %d° at midnight, want -5°", got)
+	}
+	if got := data.HourlyTemperatures[24]; got != 19 {
+		t.Errorf("got %d° at 23h, want 19°", got)
+	}
+
+	if data.Condition != "rain-slight" {
+		t.Errorf("got condition %s, want rain-slight", data.Condition)
+	}
+	if data.TemperatureToday != (temperatureData{Max: 19, Min: -5}) {
+		t.Errorf("got today's temperatures %+v, want max 19 and min -5", data.TemperatureToday)
+	}
+	if data.TemperatureYesterday == nil || *data.TemperatureYesterday != (temperatureData{Max: 4, Min: -2}) {
+		t.Errorf("got yesterday's temperatures %+v, want max 4 and min -2", data.TemperatureYesterday)
+	}
+	if data.Rainfall != 2 || data.Snowfall != 0 {
+		t.Errorf("got %vmm of rain and %vcm of snow, want 2mm and 0cm", data.Rainfall, data.Snowfall)
+	}
+
+	if got := len(data.Forecast); got != maxForecastDays {
+		t.Fatalf("got %d forecast days, want %d", got, maxForecastDays)
+	}
+	for i, day := range data.Forecast {
+		if want := time.Date(2025, time.November, 3+i, 0, 0, 0, 0, montreal); !day.Date.Equal(want) {
+			t.Errorf("forecast day %d is %v, want %v", i, day.Date, want)
+		}
+	}
+	if data.Forecast[0].Condition != "snow-fall-slight" {
+		t.Errorf("got tomorrow's condition %s, want snow-fall-slight", data.Forecast[0].Condition)
+	}
+}
+
+func TestParseOpenMeteoWeatherErrors(t *testing.T) {
+	montreal := loadTestLocation(t, "America/Montreal")
+	fixture := string(readTestData(t, "openmeteo_dst_end.json"))
+
+	for _, test := range []struct {
+		name string
+		resp string
+		now  time.Time
+		want string
+	}{
+		{"invalid", "{", time.Date(2025, time.November, 2, 15, 0, 0, 0, montreal), "unexpected EOF"},
+		{"empty", "{}", time.Date(2025, time.November, 2, 15, 0, 0, 0, montreal), "unexpected weather data length"},
+		{"another day", fixture, time.Date(2025, time.December, 2, 15, 0, 0, 0, montreal), "no hourly weather data"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseOpenMeteoWeather(test.resp, test.now)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestOpenMeteoTimezone(t *testing.T) {
+	if got := openMeteoTimezone(time.Local); got != "auto" {
+		t.Errorf("got %s for the system timezone, want auto", got)
+	}
+	if got := openMeteoTimezone(loadTestLocation(t, "America/Montreal")); got != "America/Montreal" {
+		t.Errorf("got %s for a configured timezone, want America/Montreal", got)
+	}
+}
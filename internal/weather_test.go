@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConditionIcons(t *testing.T) {
+	html, err := templates.ReadFile("screen.go.html")
+	if err != nil {
+		t.Fatalf("failed to read the template: %v", err)
+	}
+
+	// The WMO 4677 codes returned by Open-Meteo, see https://open-meteo.com/en/docs.
+	codes := []int{
+		0, 1, 2, 3, 45, 48, 51, 53, 55, 56, 57, 61, 63, 65, 66, 67, 71, 73, 75, 77, 80, 81, 82, 85, 86, 95, 96, 99,
+	}
+	for _, code := range codes {
+		// Unknown codes and conditions fall back on the cloudy icon, which would hide a missing one.
+		condition, ok := wmoConditions[code]
+		if !ok {
+			t.Errorf("no condition for WMO code %d", code)
+			continue
+		}
+		if _, ok := conditionToIcon[condition]; !ok {
+			t.Errorf("no icon for WMO code %d (%s)", code, condition)
+		}
+
+		for _, night := range []bool{false, true} {
+			for _, wind := range []float64{0, 100} {
+				icon := conditionIcon(wmoCodeToCondition(code), night, wind, UnitsMetric)
+				if icon == "" {
+					t.Errorf("empty icon for WMO code %d (%s), night: %v, wind: %v", code, condition, night, wind)
+					continue
+				}
+				if !strings.Contains(string(html), fmt.Sprintf(`<symbol id="%s"`, icon)) {
+					t.Errorf("no symbol %s in the template for WMO code %d (%s), night: %v, wind: %v",
+						icon, code, condition, night, wind)
+				}
+			}
+		}
+	}
+}
+
+func TestMetNorwaySymbolConditions(t *testing.T) {
+	for _, symbol := range []string{
+		"clearsky_day", "clearsky_night", "fair_polartwilight", "partlycloudy_day", "cloudy", "fog", "lightrain",
+		"rain", "heavyrain", "lightrainshowers_day", "rainshowers_night", "heavyrainshowers_day", "lightsleet",
+		"heavysleetshowers_day", "lightsnow", "snow", "heavysnow", "snowshowers_day", "heavysnowshowers_night",
+		"rainandthunder", "lightssnowshowersandthunder_day",
+	} {
+		condition := metNorwaySymbolToCondition(symbol)
+		if _, ok := conditionToIcon[condition]; !ok {
+			t.Errorf("MET Norway symbol %s maps to %s, which has no icon", symbol, condition)
+		}
+	}
+}
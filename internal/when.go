@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WhenOptions holds the conditions under which a Card is displayed. All the set conditions must be met.
+type WhenOptions struct {
+	SchoolDays          bool               // Only display on school days.
+	Weekdays            []time.Weekday     // Only display on these days of the week.
+	Months              []time.Month       // Only display during these months.
+	Dates               []DateRangeOptions // Only display within one of these ranges.
+	Hours               *TimeRangeConfig   // Only display when rendered within this time of the day.
+	TomorrowHasEvents   bool               // Only display if there are events tomorrow.
+	MaxTemperatureBelow *int               // Only display if today's max temperature is below.
+	MaxTemperatureAbove *int               // Only display if today's max temperature is above.
+}
+
+func (c When) ToWhenOptions() (WhenOptions, error) {
+	options := WhenOptions{
+		SchoolDays:          c.SchoolDays,
+		Hours:               c.Time,
+		TomorrowHasEvents:   c.TomorrowHasEvents,
+		MaxTemperatureBelow: c.MaxTemperatureBelow,
+		MaxTemperatureAbove: c.MaxTemperatureAbove,
+	}
+
+	for _, name := range c.Weekdays {
+		weekday, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return options, fmt.Errorf("unknown weekday: %s", name)
+		}
+		options.Weekdays = append(options.Weekdays, weekday)
+	}
+	for _, month := range c.Months {
+		if month < 1 || month > 12 {
+			return options, fmt.Errorf("unknown month: %d", month)
+		}
+		options.Months = append(options.Months, time.Month(month))
+	}
+	for _, r := range c.Dates {
+		dates, err := r.ToDateRangeOptions()
+		if err != nil {
+			return options, fmt.Errorf("failed to parse dates: %w", err)
+		}
+		options.Dates = append(options.Dates, dates)
+	}
+	return options, nil
+}
+
+// GetCardsWhenOptions returns the conditions configured for cards, keyed by title.
+func (c Config) GetCardsWhenOptions() (map[string]WhenOptions, error) {
+	options := map[string]WhenOptions{}
+	for title, card := range c.Cards {
+		when, err := card.When.ToWhenOptions()
+		if err != nil {
+			return options, fmt.Errorf("invalid conditions for card (%s): %w", title, err)
+		}
+		options[title] = when
+	}
+	return options, nil
+}
+
+// WhenEnv holds the data the conditions are evaluated against.
+type WhenEnv struct {
+	Holidays HolidayInfo
+	Calendar CalendarInfo
+	Weather  WeatherInfo
+	Timezone *time.Location // The days and times of the conditions are in this timezone.
+}
+
+// ShowWhen restricts the Card to be displayed only when the given conditions are met, on top of any conditions
+// already set. The conditions are evaluated before the Card is loaded, so hidden cards don't fetch anything.
+func (c *Card) ShowWhen(options WhenOptions, env WhenEnv) {
+	previous := c.visible
+	c.visible = func() (bool, error) {
+		if previous != nil {
+			if visible, err := previous(); err != nil || !visible {
+				return visible, err
+			}
+		}
+		return options.Met(time.Now().In(env.Timezone), &env)
+	}
+}
+
+// Met returns whether all the conditions are met at the given time. The data in env is only loaded when needed.
+func (o WhenOptions) Met(now time.Time, env *WhenEnv) (bool, error) {
+	if !o.MetOn(now) {
+		return false, nil
+	}
+	if o.Hours != nil {
+		since := sinceMidnight(now)
+		if since < o.Hours.Start || since > o.Hours.End {
+			return false, nil
+		}
+	}
+
+	if o.SchoolDays {
+		if err := env.Holidays.Load(); err != nil {
+			return false, err
+		}
+		if !env.Holidays.SchoolDay {
+			return false, nil
+		}
+	}
+	if o.TomorrowHasEvents {
+		if err := env.Calendar.Load(); err != nil {
+			return false, err
+		}
+		if len(env.Calendar.Tomorrow) == 0 {
+			return false, nil
+		}
+	}
+	if o.MaxTemperatureBelow != nil || o.MaxTemperatureAbove != nil {
+		if err := env.Weather.Load(); err != nil {
+			return false, err
+		}
+		if o.MaxTemperatureBelow != nil && env.Weather.MaxTemperature >= *o.MaxTemperatureBelow {
+			return false, nil
+		}
+		if o.MaxTemperatureAbove != nil && env.Weather.MaxTemperature <= *o.MaxTemperatureAbove {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// MetOn returns whether the conditions on the date are met on the day of the given time. The time of the day and the
+// data only known for today aren't considered.
+func (o WhenOptions) MetOn(day time.Time) bool {
+	if len(o.Weekdays) > 0 && !slices.Contains(o.Weekdays, day.Weekday()) {
+		return false
+	}
+	if len(o.Months) > 0 && !slices.Contains(o.Months, day.Month()) {
+		return false
+	}
+	if len(o.Dates) > 0 && !slices.ContainsFunc(o.Dates, func(r DateRangeOptions) bool { return r.Includes(day) }) {
+		return false
+	}
+	return true
+}